@@ -0,0 +1,80 @@
+package joycon
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/flynn/hid"
+)
+
+// Backend enumerates and opens controllers for a device path scheme.
+type Backend interface {
+	Devices() ([]*hid.DeviceInfo, error)
+	Open(path string) (Transport, error)
+}
+
+var (
+	muBackends sync.RWMutex
+	backends   = map[string]Backend{}
+)
+
+// serialBackend is a Backend that knows the serial number of its devices.
+type serialBackend interface {
+	Serial(path string) string
+}
+
+// DeviceSerial returns the serial number of a device, the Bluetooth MAC
+// address for controllers, or "" when its backend does not report one.
+func DeviceSerial(info *hid.DeviceInfo) string {
+	if i := strings.Index(info.Path, ":"); i > 0 {
+		if b, ok := lookupBackend(info.Path[:i]); ok {
+			if sb, ok := b.(serialBackend); ok {
+				return sb.Serial(info.Path[i+1:])
+			}
+		}
+	}
+	return ""
+}
+
+// sameMAC reports whether a serial number string is the address mac.
+func sameMAC(serial string, mac net.HardwareAddr) bool {
+	a, err := net.ParseMAC(serial)
+	return err == nil && bytes.Equal(a, mac)
+}
+
+// RegisterBackend makes b reachable through "name:path" device paths.
+func RegisterBackend(name string, b Backend) {
+	muBackends.Lock()
+	defer muBackends.Unlock()
+	backends[name] = b
+}
+
+func lookupBackend(name string) (Backend, bool) {
+	muBackends.RLock()
+	defer muBackends.RUnlock()
+	b, ok := backends[name]
+	return b, ok
+}
+
+// OpenTransport opens devicePath the same way NewJoycon does.
+// A "name:" prefix selects a registered Backend, other paths go to github.com/flynn/hid.
+func OpenTransport(devicePath string) (Transport, error) {
+	if i := strings.Index(devicePath, ":"); i > 0 {
+		if b, ok := lookupBackend(devicePath[:i]); ok {
+			return b.Open(devicePath[i+1:])
+		}
+	}
+	return openHID(devicePath)
+}
+
+// devicesFor returns the enumerator of the backend that devicePath belongs to.
+func devicesFor(devicePath string) func() ([]*hid.DeviceInfo, error) {
+	if i := strings.Index(devicePath, ":"); i > 0 {
+		if b, ok := lookupBackend(devicePath[:i]); ok {
+			return b.Devices
+		}
+	}
+	return hid.Devices
+}
@@ -0,0 +1,124 @@
+package joycon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Button is a bit of State.Buttons.
+type Button uint32
+
+// Buttons of standard input reports.
+const (
+	ButtonY Button = 1 << iota
+	ButtonX
+	ButtonB
+	ButtonA
+	ButtonRightSR
+	ButtonRightSL
+	ButtonR
+	ButtonZR
+	ButtonMinus
+	ButtonPlus
+	ButtonRStick
+	ButtonLStick
+	ButtonHome
+	ButtonCapture
+	_
+	ButtonChargingGrip
+	ButtonDown
+	ButtonUp
+	ButtonRight
+	ButtonLeft
+	ButtonLeftSR
+	ButtonLeftSL
+	ButtonL
+	ButtonZL
+)
+
+// AllButtons lists every Button in bit order.
+var AllButtons = []Button{
+	ButtonY, ButtonX, ButtonB, ButtonA, ButtonRightSR, ButtonRightSL, ButtonR, ButtonZR,
+	ButtonMinus, ButtonPlus, ButtonRStick, ButtonLStick, ButtonHome, ButtonCapture, ButtonChargingGrip,
+	ButtonDown, ButtonUp, ButtonRight, ButtonLeft, ButtonLeftSR, ButtonLeftSL, ButtonL, ButtonZL,
+}
+
+// ButtonMask covers every defined Button.
+const ButtonMask = ButtonY | ButtonX | ButtonB | ButtonA | ButtonRightSR | ButtonRightSL | ButtonR | ButtonZR |
+	ButtonMinus | ButtonPlus | ButtonRStick | ButtonLStick | ButtonHome | ButtonCapture | ButtonChargingGrip |
+	ButtonDown | ButtonUp | ButtonRight | ButtonLeft | ButtonLeftSR | ButtonLeftSL | ButtonL | ButtonZL
+
+var buttonNames = map[Button]string{
+	ButtonY:            "Y",
+	ButtonX:            "X",
+	ButtonB:            "B",
+	ButtonA:            "A",
+	ButtonRightSR:      "RightSR",
+	ButtonRightSL:      "RightSL",
+	ButtonR:            "R",
+	ButtonZR:           "ZR",
+	ButtonMinus:        "Minus",
+	ButtonPlus:         "Plus",
+	ButtonRStick:       "RStick",
+	ButtonLStick:       "LStick",
+	ButtonHome:         "Home",
+	ButtonCapture:      "Capture",
+	ButtonChargingGrip: "ChargingGrip",
+	ButtonDown:         "Down",
+	ButtonUp:           "Up",
+	ButtonRight:        "Right",
+	ButtonLeft:         "Left",
+	ButtonLeftSR:       "LeftSR",
+	ButtonLeftSL:       "LeftSL",
+	ButtonL:            "L",
+	ButtonZL:           "ZL",
+}
+
+// Pressed reports whether all buttons of x are set in b.
+func (b Button) Pressed(x Button) bool {
+	return x != 0 && b&x == x
+}
+
+// Each calls fn for every single Button set in b, in bit order.
+func (b Button) Each(fn func(Button)) {
+	for _, x := range AllButtons {
+		if b&x != 0 {
+			fn(x)
+		}
+	}
+}
+
+func (b Button) String() string {
+	if b == 0 {
+		return "None"
+	}
+	names := []string{}
+	b.Each(func(x Button) {
+		names = append(names, buttonNames[x])
+	})
+	if rest := b &^ ButtonMask; rest != 0 {
+		names = append(names, fmt.Sprintf("%#x", uint32(rest)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Pressed reports whether all buttons of x are held.
+func (s State) Pressed(x Button) bool {
+	return s.Buttons.Pressed(x)
+}
+
+// buttonLayouts are the buttons physically present on each controller.
+var buttonLayouts = map[DeviceType]Button{
+	JoyConL: ButtonMinus | ButtonLStick | ButtonCapture |
+		ButtonDown | ButtonUp | ButtonRight | ButtonLeft | ButtonLeftSR | ButtonLeftSL | ButtonL | ButtonZL,
+	JoyConR: ButtonY | ButtonX | ButtonB | ButtonA | ButtonRightSR | ButtonRightSL | ButtonR | ButtonZR |
+		ButtonPlus | ButtonRStick | ButtonHome,
+	ProCon: ButtonY | ButtonX | ButtonB | ButtonA | ButtonR | ButtonZR |
+		ButtonMinus | ButtonPlus | ButtonRStick | ButtonLStick | ButtonHome | ButtonCapture |
+		ButtonDown | ButtonUp | ButtonRight | ButtonLeft | ButtonL | ButtonZL,
+}
+
+// Buttons returns the buttons physically present on the controller type.
+func (dt DeviceType) Buttons() Button {
+	return buttonLayouts[dt]
+}
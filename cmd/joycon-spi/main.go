@@ -0,0 +1,245 @@
+// joycon-spi backs up the whole SPI flash of a controller to a file and
+// restores the user-writable regions from such a backup.
+//
+//	joycon-spi -backup joycon.spi
+//	joycon-spi -restore joycon.spi          # show differences only
+//	joycon-spi -restore joycon.spi -write   # write differences back
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+
+	"github.com/nobonobo/joycon"
+)
+
+// Backup file layout (little endian):
+//
+//	"JCSP" version(1) deviceType(2) mac(6) firmwareMajor(1) firmwareMinor(1)
+//	imageSize(4) sha256(image)(32) image
+const (
+	magic      = "JCSP"
+	version    = 1
+	headerSize = 4 + 1 + 2 + 6 + 1 + 1 + 4 + sha256.Size
+)
+
+type header struct {
+	Type     joycon.DeviceType
+	MAC      net.HardwareAddr
+	Major    uint8
+	Minor    uint8
+	Size     uint32
+	Checksum [sha256.Size]byte
+}
+
+func (h *header) MarshalBinary() ([]byte, error) {
+	b := make([]byte, headerSize)
+	copy(b[0:4], magic)
+	b[4] = version
+	binary.LittleEndian.PutUint16(b[5:7], uint16(h.Type))
+	copy(b[7:13], h.MAC)
+	b[13] = h.Major
+	b[14] = h.Minor
+	binary.LittleEndian.PutUint32(b[15:19], h.Size)
+	copy(b[19:], h.Checksum[:])
+	return b, nil
+}
+
+func (h *header) UnmarshalBinary(b []byte) error {
+	if len(b) < headerSize || string(b[0:4]) != magic || b[4] != version {
+		return errors.New("not a joycon-spi backup")
+	}
+	h.Type = joycon.DeviceType(binary.LittleEndian.Uint16(b[5:7]))
+	h.MAC = net.HardwareAddr(append([]byte(nil), b[7:13]...))
+	h.Major = b[13]
+	h.Minor = b[14]
+	h.Size = binary.LittleEndian.Uint32(b[15:19])
+	copy(h.Checksum[:], b[19:headerSize])
+	return nil
+}
+
+func (h *header) String() string {
+	return fmt.Sprintf("%v mac:%s firmware:%d.%02d size:%d sha256:%x",
+		h.Type, h.MAC, h.Major, h.Minor, h.Size, h.Checksum)
+}
+
+// read reads [addr, addr+length) in SPIChunkSize pieces, retrying timeouts.
+func read(jc *joycon.Joycon, addr uint32, length int, progress func(done int)) ([]byte, error) {
+	res := make([]byte, 0, length)
+	for len(res) < length {
+		n := length - len(res)
+		if n > joycon.SPIChunkSize {
+			n = joycon.SPIChunkSize
+		}
+		var data []byte
+		var err error
+		for retry := 0; retry < 5; retry++ {
+			data, err = jc.ReadSPI(addr+uint32(len(res)), n)
+			if !errors.Is(err, joycon.ErrTimeout) {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %#x: %w", addr+uint32(len(res)), err)
+		}
+		res = append(res, data...)
+		if progress != nil {
+			progress(len(res))
+		}
+	}
+	return res, nil
+}
+
+func backup(jc *joycon.Joycon, name string) error {
+	di := jc.DeviceInfo()
+	last := -1
+	image, err := read(jc, 0, joycon.SPISize, func(done int) {
+		if p := done * 100 / joycon.SPISize; p != last {
+			last = p
+			fmt.Fprintf(os.Stderr, "\rbackup: %3d%%", p)
+		}
+	})
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+	h := &header{
+		Type:     di.Type,
+		MAC:      di.MAC,
+		Major:    di.FirmwareMajor,
+		Minor:    di.FirmwareMinor,
+		Size:     uint32(len(image)),
+		Checksum: sha256.Sum256(image),
+	}
+	b, _ := h.MarshalBinary()
+	if err := os.WriteFile(name, append(b, image...), 0644); err != nil {
+		return err
+	}
+	log.Println("saved:", h)
+	return nil
+}
+
+func load(name string) (*header, []byte, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	h := &header{}
+	if err := h.UnmarshalBinary(b); err != nil {
+		return nil, nil, err
+	}
+	image := b[headerSize:]
+	if len(image) != int(h.Size) || len(image) != joycon.SPISize {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	if sha256.Sum256(image) != h.Checksum {
+		return nil, nil, errors.New("checksum mismatch")
+	}
+	return h, image, nil
+}
+
+// span is a run of differing bytes.
+type span struct {
+	addr uint32
+	data []byte
+}
+
+func diff(addr uint32, want, got []byte) []span {
+	res := []span{}
+	for i := 0; i < len(want); i++ {
+		if want[i] == got[i] {
+			continue
+		}
+		j := i
+		for j < len(want) && want[j] != got[j] {
+			j++
+		}
+		res = append(res, span{addr: addr + uint32(i), data: want[i:j]})
+		i = j
+	}
+	return res
+}
+
+func restore(jc *joycon.Joycon, name string, write, force bool) error {
+	h, image, err := load(name)
+	if err != nil {
+		return err
+	}
+	log.Println("backup:", h)
+	di := jc.DeviceInfo()
+	if h.Type != di.Type {
+		return fmt.Errorf("backup is for %v, device is %v", h.Type, di.Type)
+	}
+	if !bytes.Equal(h.MAC, di.MAC) && !force {
+		return fmt.Errorf("backup is from %s, device is %s (use -force)", h.MAC, di.MAC)
+	}
+	for _, r := range joycon.WritableSPIRegions() {
+		got, err := read(jc, r.Addr, r.Length, nil)
+		if err != nil {
+			return err
+		}
+		spans := diff(r.Addr, image[r.Addr:int(r.Addr)+r.Length], got)
+		for _, s := range spans {
+			fmt.Printf("%#06x: % X -> % X\n", s.addr, got[s.addr-r.Addr:int(s.addr-r.Addr)+len(s.data)], s.data)
+			if !write {
+				continue
+			}
+			if err := jc.WriteSPI(s.addr, s.data); err != nil {
+				return err
+			}
+		}
+		if len(spans) == 0 {
+			fmt.Printf("%#06x+%#x: no difference\n", r.Addr, r.Length)
+		}
+	}
+	if !write {
+		log.Println("dry run, use -write to restore")
+	}
+	return nil
+}
+
+func main() {
+	log.SetFlags(log.Lmicroseconds)
+	dev := flag.String("dev", "", "device path, default: first found controller")
+	backupFile := flag.String("backup", "", "dump the whole SPI flash to file")
+	restoreFile := flag.String("restore", "", "restore user-writable regions from file")
+	write := flag.Bool("write", false, "restore: write differences to the device")
+	force := flag.Bool("force", false, "restore: allow a backup of another controller")
+	flag.Parse()
+	if (*backupFile == "") == (*restoreFile == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := *dev
+	if path == "" {
+		devices, err := joycon.Search()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		path = devices[0].Path
+	}
+	jc, err := joycon.NewJoycon(path, false)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer jc.Close()
+	log.Println("connected:", jc.Name(), jc.DeviceInfo().MAC)
+	if *backupFile != "" {
+		err = backup(jc, *backupFile)
+	} else {
+		err = restore(jc, *restoreFile, *write, *force)
+	}
+	if err != nil {
+		log.Println(err)
+		jc.Close()
+		os.Exit(1)
+	}
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 
+	"github.com/flynn/hid"
 	"github.com/nobonobo/joycon"
 )
 
 func main() {
-	devices, err := joycon.Search()
+	backend := flag.String("backend", "", "device backend(e.g. hidraw), default: flynn/hid")
+	flag.Parse()
+	var devices []*hid.DeviceInfo
+	var err error
+	if *backend == "" {
+		devices, err = joycon.Search()
+	} else {
+		devices, err = joycon.SearchBackend(*backend)
+	}
 	if err != nil {
 		log.Fatalln(err)
 	}
 	for _, device := range devices {
-		fmt.Printf("%s: %q\n", device.Product, device.Path)
+		fmt.Printf("%s: %q kernel-driver:%v\n", device.Product, device.Path, joycon.KernelDriverBound(device))
 	}
 }
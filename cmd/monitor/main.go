@@ -29,8 +29,8 @@ func main() {
 		log.Fatalln(err)
 	}
 	jcs := []*joycon.Joycon{}
-	for _, dev := range devices {
-		jc, err := joycon.NewJoycon(dev.Path, false)
+	for i, dev := range devices {
+		jc, err := joycon.NewJoycon(dev.Path, false, joycon.WithPlayerLights(joycon.LED1<<uint(i%4), 0))
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -99,17 +99,17 @@ func main() {
 							bars["lax"].Set(calc(last.Accel.X))
 							bars["lay"].Set(calc(last.Accel.Y))
 							bars["laz"].Set(calc(last.Accel.Z))
-							bars["lgx"].Set(calc(last.Gyro.X))
-							bars["lgy"].Set(calc(last.Gyro.Y))
-							bars["lgz"].Set(calc(last.Gyro.Z))
+							bars["lgx"].Set(calc(last.Gyro.X / 250))
+							bars["lgy"].Set(calc(last.Gyro.Y / 250))
+							bars["lgz"].Set(calc(last.Gyro.Z / 250))
 						}
 						if jc.IsRight() || jc.IsProCon() {
 							bars["rax"].Set(calc(last.Accel.X))
 							bars["ray"].Set(calc(last.Accel.Y))
 							bars["raz"].Set(calc(last.Accel.Z))
-							bars["rgx"].Set(calc(last.Gyro.X))
-							bars["rgy"].Set(calc(last.Gyro.Y))
-							bars["rgz"].Set(calc(last.Gyro.Z))
+							bars["rgx"].Set(calc(last.Gyro.X / 250))
+							bars["rgy"].Set(calc(last.Gyro.Y / 250))
+							bars["rgz"].Set(calc(last.Gyro.Z / 250))
 						}
 						sensors = sensors[0:0]
 					}
@@ -10,9 +10,10 @@ import (
 )
 
 var (
-	oldButtons uint32
+	buttons    joycon.ButtonDecoder
 	oldStick   joycon.Vec2
 	oldBattery int
+	oldCharge  bool
 	rumbleData = []joycon.RumbleSet{
 		{
 			{HiFreq: 64, HiAmp: 0, LoFreq: 64, LoAmp: 0},   // HiCoil
@@ -36,60 +37,50 @@ type Joycon struct {
 
 func (jc *Joycon) stateHandle(s joycon.State) {
 	defer func() {
-		oldButtons = s.Buttons
 		oldStick = s.RightAdj
 	}()
-	if oldBattery != s.Battery {
-		log.Println("battery:", s.Battery, "%")
+	if oldBattery != s.Battery || oldCharge != s.Charging {
+		log.Println("battery:", s.Battery, "%", s.BatteryLevel, "charging:", s.Charging)
 	}
 	oldBattery = s.Battery
-	downButtons := s.Buttons & ^oldButtons
-	upButtons := ^s.Buttons & oldButtons
-	switch {
-	case downButtons == 0:
-	default:
-		log.Printf("down: %06X", downButtons)
-	case downButtons>>6&1 == 1: // R
-		jc.stop = true
-	case downButtons>>7&1 == 1: // ZR
-		jc.scroll = true
-	case downButtons>>0&1 == 1: // Y
-		jc.SendRumble(rumbleData...)
-		robotgo.MouseClick("left")
-	case downButtons>>1&1 == 1: // X
-		jc.SendRumble(rumbleData...)
-		robotgo.MouseClick("center")
-	case downButtons>>3&1 == 1: // A
-		jc.SendRumble(rumbleData...)
-		robotgo.MouseClick("right")
-	case downButtons>>2&1 == 1: // B
-		robotgo.KeyTap("space")
-	case downButtons>>4&1 == 1: // SR
-		robotgo.Scroll(0, -2)
-	case downButtons>>5&1 == 1: // SL
-		robotgo.Scroll(0, +2)
-	case downButtons>>9&1 == 1: // +
-		robotgo.KeyTap("escape")
-	case downButtons>>10&1 == 1: // RStick Push
-	case downButtons>>12&1 == 1: // Home
-	}
-	switch {
-	case upButtons == 0:
-	default:
-		log.Printf("up  : %06X", upButtons)
-	case upButtons>>6&1 == 1: // R
-		jc.stop = false
-	case upButtons>>7&1 == 1: // ZR
-		jc.scroll = false
-	case upButtons>>0&1 == 1: // Y
-	case upButtons>>1&1 == 1: // X
-	case upButtons>>2&1 == 1: // B
-	case upButtons>>3&1 == 1: // A
-	case upButtons>>4&1 == 1: // SR
-	case upButtons>>5&1 == 1: // SL
-	case upButtons>>9&1 == 1: // +
-	case upButtons>>10&1 == 1: // RStick Push
-	case upButtons>>12&1 == 1: // Home
+	oldCharge = s.Charging
+	for _, ev := range buttons.Decode(s) {
+		if ev.Type == joycon.ButtonEventUp {
+			switch ev.Button {
+			default:
+				log.Printf("up  : %v held %v", ev.Button, ev.Held)
+			case joycon.ButtonR:
+				jc.stop = false
+			case joycon.ButtonZR:
+				jc.scroll = false
+			}
+			continue
+		}
+		switch ev.Button {
+		default:
+			log.Printf("down: %v", ev.Button)
+		case joycon.ButtonR:
+			jc.stop = true
+		case joycon.ButtonZR:
+			jc.scroll = true
+		case joycon.ButtonY:
+			jc.SendRumble(rumbleData...)
+			robotgo.MouseClick("left")
+		case joycon.ButtonX:
+			jc.SendRumble(rumbleData...)
+			robotgo.MouseClick("center")
+		case joycon.ButtonA:
+			jc.SendRumble(rumbleData...)
+			robotgo.MouseClick("right")
+		case joycon.ButtonB:
+			robotgo.KeyTap("space")
+		case joycon.ButtonRightSR:
+			robotgo.Scroll(0, -2)
+		case joycon.ButtonRightSL:
+			robotgo.Scroll(0, +2)
+		case joycon.ButtonPlus:
+			robotgo.KeyTap("escape")
+		}
 	}
 	if jc.scroll {
 		jc.scrollPos += s.RightAdj.Y * s.RightAdj.Y * s.RightAdj.Y
@@ -146,12 +137,12 @@ func (jc *Joycon) apply() {
 
 func (jc *Joycon) sensorHandle(s joycon.Sensor) {
 	if jc.IsLeft() || jc.IsProCon() {
-		jc.dx -= s.Gyro.Z * 64
-		jc.dy += s.Gyro.Y * 64
+		jc.dx -= s.Gyro.Z / 4
+		jc.dy += s.Gyro.Y / 4
 	}
 	if jc.IsRight() {
-		jc.dx += s.Gyro.Z * 64
-		jc.dy -= s.Gyro.Y * 64
+		jc.dx += s.Gyro.Z / 4
+		jc.dy -= s.Gyro.Y / 4
 	}
 }
 
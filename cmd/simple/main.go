@@ -16,6 +16,8 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
+	di := jc.DeviceInfo()
+	fmt.Printf("firmware:%s mac:%s\n", di.Firmware(), di.MAC)
 	s := <-jc.State()
 	fmt.Printf("%#v\n", s.Buttons)  // Button bits
 	fmt.Printf("%#v\n", s.LeftAdj)  // Left Analog Stick State
@@ -0,0 +1,53 @@
+package joycon
+
+import (
+	"image/color"
+)
+
+// Colors is the controller color block of SPI 0x6050 (ColorRegion).
+type Colors struct {
+	Body      color.RGBA
+	Buttons   color.RGBA
+	LeftGrip  color.RGBA
+	RightGrip color.RGBA
+}
+
+// UnmarshalBinary ...
+func (c *Colors) UnmarshalBinary(b []byte) error {
+	if len(b) < ColorRegion.Length {
+		return &ShortReportError{Length: len(b), Want: ColorRegion.Length}
+	}
+	for i, v := range []*color.RGBA{&c.Body, &c.Buttons, &c.LeftGrip, &c.RightGrip} {
+		*v = color.RGBA{b[i*3], b[i*3+1], b[i*3+2], 0xff}
+	}
+	return nil
+}
+
+// MarshalBinary ...
+func (c Colors) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, ColorRegion.Length)
+	for _, v := range []color.RGBA{c.Body, c.Buttons, c.LeftGrip, c.RightGrip} {
+		b = append(b, v.R, v.G, v.B)
+	}
+	return b, nil
+}
+
+// Colors returns the body, buttons and grip colors read on connect.
+// Grip colors are only meaningful on the Pro Controller.
+func (jc *Joycon) Colors() Colors {
+	if c, ok := jc.colors.Load().(Colors); ok {
+		return c
+	}
+	return Colors{}
+}
+
+// SetColors writes the color block with WriteSPI, so it is read back and verified.
+// The new colors show on the Switch after the controller reconnects.
+func (jc *Joycon) SetColors(c Colors) error {
+	b, _ := c.MarshalBinary()
+	if err := jc.WriteSPI(ColorRegion.Addr, b); err != nil {
+		return err
+	}
+	jc.colors.Store(c)
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"log"
+	"net"
+	"time"
 )
 
 const (
@@ -12,8 +14,10 @@ const (
 	SensorRes = 65535
 	GyroGain  = 4000
 	//AccelK     = GyroRange / SensorRes / 1000
+	// Deprecated: use IMUConfig.AccelCoeff.
 	AccelK = 1.0 / 4096
 	//GyroK      = GyroGain / SensorRes
+	// Deprecated: use IMUConfig.GyroCoeff.
 	GyroK = 1.0 / 4096
 )
 
@@ -55,26 +59,104 @@ func (v Vec3) String() string {
 	)
 }
 
+// BatteryLevel is the battery level reported in input reports.
+type BatteryLevel byte
+
+// Battery levels.
+const (
+	BatteryEmpty    BatteryLevel = 0
+	BatteryCritical BatteryLevel = 2
+	BatteryLow      BatteryLevel = 4
+	BatteryMedium   BatteryLevel = 6
+	BatteryFull     BatteryLevel = 8
+)
+
+func (l BatteryLevel) String() string {
+	switch l {
+	case BatteryEmpty:
+		return "Empty"
+	case BatteryCritical:
+		return "Critical"
+	case BatteryLow:
+		return "Low"
+	case BatteryMedium:
+		return "Medium"
+	case BatteryFull:
+		return "Full"
+	}
+	return fmt.Sprintf("BatteryLevel(%d)", byte(l))
+}
+
+// ConnectionInfo is the low nibble of the battery byte.
+type ConnectionInfo byte
+
+// JoyCon reports a Joy-Con connected on its own.
+func (c ConnectionInfo) JoyCon() bool {
+	return (c>>1)&3 == 3
+}
+
+// Grip reports a Pro Controller or a Joy-Con in the Charging Grip.
+func (c ConnectionInfo) Grip() bool {
+	return (c>>1)&3 == 0
+}
+
+// Powered reports power from the Switch rail or USB.
+func (c ConnectionInfo) Powered() bool {
+	return c&1 != 0
+}
+
+func (c ConnectionInfo) String() string {
+	s := "JoyCon"
+	if c.Grip() {
+		s = "Grip"
+	}
+	if c.Powered() {
+		s += "+Powered"
+	}
+	return s
+}
+
 // State ...
 type State struct {
-	Tick     byte
-	Battery  int
-	Buttons  uint32
-	Left     Stick
-	Right    Stick
-	LeftAdj  Vec2
-	RightAdj Vec2
-	Err      error
+	Tick           byte
+	Battery        int // percent of BatteryLevel
+	BatteryLevel   BatteryLevel
+	Charging       bool
+	ConnectionInfo ConnectionInfo
+	Buttons        Button
+	Left           Stick
+	Right          Stick
+	LeftAdj        Vec2
+	RightAdj       Vec2
+	// Hat is the hat direction of simple HID mode reports.
+	Hat Hat
+	// LowResolution is set when the state came from a simple HID mode report:
+	// sticks are 8-bit and there is no Tick or Battery.
+	LowResolution bool
+	// Time is when the report was received.
+	Time time.Time
+	// Down and Up are the buttons pressed and released since the previous
+	// delivered State, including changes of dropped States.
+	Down Button
+	Up   Button
+	Err  error
 }
 
 // UnmarshalBinary ...
 func (s *State) UnmarshalBinary(b []byte) error {
 	if len(b) < 15 {
-		return fmt.Errorf("invalid bytes length")
+		return &ShortReportError{Length: len(b), Want: 15}
 	}
 	s.Tick = b[1]
-	s.Battery = (int(b[2]) >> 4) * 100 / 8
-	s.Buttons = uint32(b[3]) | uint32(b[4])<<8 | uint32(b[5])<<16
+	s.BatteryLevel = BatteryLevel(b[2]>>4) & 0x0e
+	s.Charging = b[2]&0x10 != 0
+	s.ConnectionInfo = ConnectionInfo(b[2] & 0x0f)
+	s.Battery = int(s.BatteryLevel) * 100 / 8
+	if s.Battery > 100 {
+		// levels above BatteryFull are not documented
+		s.Battery = 100
+	}
+	s.Buttons = Button(b[3]) | Button(b[4])<<8 | Button(b[5])<<16
 	if !bytes.Equal(b[6:9], []byte{0, 0, 0}) {
 		s.Left.X = int16(b[6]) | int16(b[7]&0x0f)<<8
 		s.Left.Y = int16(b[7]>>4) | int16(b[8])<<4
@@ -86,7 +168,150 @@ func (s *State) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
-// Sensor ...
+// Hat is a hat switch direction.
+type Hat byte
+
+// Hat directions, clockwise from Up.
+const (
+	HatUp Hat = iota
+	HatUpRight
+	HatRight
+	HatDownRight
+	HatDown
+	HatDownLeft
+	HatLeft
+	HatUpLeft
+	HatNeutral
+)
+
+func (h Hat) String() string {
+	names := [...]string{"Up", "UpRight", "Right", "DownRight", "Down", "DownLeft", "Left", "UpLeft", "Neutral"}
+	if int(h) < len(names) {
+		return names[h]
+	}
+	return fmt.Sprintf("Hat(%d)", byte(h))
+}
+
+// simpleHIDButtons maps the 16 button bits of a 0x3F report to State.Buttons bits.
+var simpleHIDButtons = map[DeviceType][16]Button{
+	JoyConL: {
+		ButtonLeft, ButtonDown, ButtonUp, ButtonRight, ButtonLeftSL, ButtonLeftSR, 0, 0,
+		ButtonMinus, ButtonPlus, ButtonLStick, ButtonRStick, ButtonHome, ButtonCapture, ButtonL, ButtonZL,
+	},
+	JoyConR: {
+		ButtonA, ButtonX, ButtonB, ButtonY, ButtonRightSL, ButtonRightSR, 0, 0,
+		ButtonMinus, ButtonPlus, ButtonLStick, ButtonRStick, ButtonHome, ButtonCapture, ButtonR, ButtonZR,
+	},
+	ProCon: {
+		ButtonB, ButtonA, ButtonY, ButtonX, ButtonL, ButtonR, ButtonZL, ButtonZR,
+		ButtonMinus, ButtonPlus, ButtonLStick, ButtonRStick, ButtonHome, ButtonCapture, 0, 0,
+	},
+}
+
+// hatButtons maps Pro Controller hat directions to D-pad bits of State.Buttons.
+var hatButtons = [...]Button{
+	HatUp:        ButtonUp,
+	HatUpRight:   ButtonUp | ButtonRight,
+	HatRight:     ButtonRight,
+	HatDownRight: ButtonDown | ButtonRight,
+	HatDown:      ButtonDown,
+	HatDownLeft:  ButtonDown | ButtonLeft,
+	HatLeft:      ButtonLeft,
+	HatUpLeft:    ButtonUp | ButtonLeft,
+}
+
+// UnmarshalSimpleHID decodes a simple HID mode (0x3F) input report of device type dt.
+// Sticks are scaled from 8 to 12 bits, Joycon normalizes them from the 8-bit center.
+func (s *State) UnmarshalSimpleHID(b []byte, dt DeviceType) error {
+	if len(b) < 12 {
+		return &ShortReportError{Length: len(b), Want: 12}
+	}
+	buttons, ok := simpleHIDButtons[dt]
+	if !ok {
+		return fmt.Errorf("unknown device type: %v", dt)
+	}
+	s.LowResolution = true
+	s.Buttons = 0
+	raw := uint16(b[1]) | uint16(b[2])<<8
+	for i, button := range buttons {
+		if raw&(1<<uint(i)) != 0 {
+			s.Buttons |= button
+		}
+	}
+	s.Hat = Hat(b[3] & 0x0f)
+	if dt == ProCon && int(s.Hat) < len(hatButtons) {
+		s.Buttons |= hatButtons[s.Hat]
+	}
+	// 16-bit little endian axes, only the high byte is significant. Y grows downward.
+	s.Left.X = int16(b[5]) << 4
+	s.Left.Y = int16(0xff-b[7]) << 4
+	s.Right.X = int16(b[9]) << 4
+	s.Right.Y = int16(0xff-b[11]) << 4
+	return nil
+}
+
+// SubcommandReply is a parsed 0x21 input report.
+type SubcommandReply struct {
+	State State
+	ACK   byte
+	ID    byte
+	Data  []byte
+}
+
+// UnmarshalBinary ...
+func (r *SubcommandReply) UnmarshalBinary(b []byte) error {
+	if len(b) < 15 {
+		return &ShortReportError{Length: len(b), Want: 15}
+	}
+	if err := r.State.UnmarshalBinary(b); err != nil {
+		return err
+	}
+	r.ACK = b[13]
+	r.ID = b[14]
+	r.Data = append([]byte(nil), b[15:]...)
+	return nil
+}
+
+// Acked reports whether the ACK bit(0x80) is set.
+func (r *SubcommandReply) Acked() bool {
+	return r.ACK&0x80 != 0
+}
+
+// DeviceInfo is the reply of subcommand 0x02 (Request device info).
+type DeviceInfo struct {
+	FirmwareMajor uint8
+	FirmwareMinor uint8
+	Type          DeviceType
+	MAC           net.HardwareAddr
+}
+
+// UnmarshalBinary decodes the subcommand reply data.
+func (di *DeviceInfo) UnmarshalBinary(b []byte) error {
+	if len(b) < 10 {
+		return &ShortReportError{Length: len(b), Want: 10}
+	}
+	di.FirmwareMajor = b[0]
+	di.FirmwareMinor = b[1]
+	switch b[2] {
+	case 0x01:
+		di.Type = JoyConL
+	case 0x02:
+		di.Type = JoyConR
+	case 0x03:
+		di.Type = ProCon
+	default:
+		return &UnknownProductError{Type: b[2]}
+	}
+	di.MAC = net.HardwareAddr(append([]byte(nil), b[4:10]...))
+	return nil
+}
+
+// Firmware ...
+func (di DeviceInfo) Firmware() string {
+	return fmt.Sprintf("%d.%02d", di.FirmwareMajor, di.FirmwareMinor)
+}
+
+// Sensor is a 6-axis sample, Accel in G and Gyro in degrees per second.
 type Sensor struct {
 	Tick  byte
 	Gyro  Vec3
@@ -96,31 +321,33 @@ type Sensor struct {
 // Sensors ...
 type Sensors [3]Sensor
 
-// UnmarshalBinary ...
+// UnmarshalBinary decodes a 0x30 report with DefaultIMUConfig and no calibration.
 func (s *Sensors) UnmarshalBinary(b []byte) error {
+	return s.UnmarshalBinaryWith(b, DefaultIMUConfig, nil)
+}
+
+// UnmarshalBinaryWith decodes a 0x30 report scaled to the ranges of c.
+// Offsets and sensitivities of cal are applied when it is not nil.
+func (s *Sensors) UnmarshalBinaryWith(b []byte, c IMUConfig, cal *IMUCalibration) error {
 	if len(b) < 49 {
-		return fmt.Errorf("invalid bytes length")
+		return &ShortReportError{Length: len(b), Want: 49}
+	}
+	if cal == nil {
+		cal = &IMUCalibration{}
+	}
+	g, dps := c.AccelRange.G(), c.GyroRange.DPS()
+	raw := func(i int) float32 {
+		return float32(int16(binary.LittleEndian.Uint16(b[i : i+2])))
 	}
 	for n := 0; n < 3; n++ {
+		p := 13 + n*12
 		s[n].Tick = b[1] - byte(2-n)
-		s[n].Accel.X = AccelK * float32(int16(
-			binary.LittleEndian.Uint16(b[13+n*12:15+n*12]),
-		))
-		s[n].Accel.Y = AccelK * float32(int16(
-			binary.LittleEndian.Uint16(b[15+n*12:17+n*12]),
-		))
-		s[n].Accel.Z = AccelK * float32(int16(
-			binary.LittleEndian.Uint16(b[17+n*12:19+n*12]),
-		))
-		s[n].Gyro.X = GyroK * float32(int16(
-			binary.LittleEndian.Uint16(b[19+n*12:21+n*12]),
-		))
-		s[n].Gyro.Y = GyroK * float32(int16(
-			binary.LittleEndian.Uint16(b[21+n*12:23+n*12]),
-		))
-		s[n].Gyro.Z = GyroK * float32(int16(
-			binary.LittleEndian.Uint16(b[23+n*12:25+n*12]),
-		))
+		s[n].Accel.X = cal.accel(0, raw(p), g)
+		s[n].Accel.Y = cal.accel(1, raw(p+2), g)
+		s[n].Accel.Z = cal.accel(2, raw(p+4), g)
+		s[n].Gyro.X = cal.gyro(0, raw(p+6), dps)
+		s[n].Gyro.Y = cal.gyro(1, raw(p+8), dps)
+		s[n].Gyro.Z = cal.gyro(2, raw(p+10), dps)
 	}
 	return nil
 }
@@ -134,6 +361,9 @@ type CalibInfo struct {
 
 // UnmarshalBinary ...
 func (ci *CalibInfo) UnmarshalBinary(b []byte) error {
+	if len(b) < 9 {
+		return &ShortReportError{Length: len(b), Want: 9}
+	}
 	ci.Max.X = int16(b[0]) | int16(b[1]&0xf)<<8
 	ci.Max.Y = int16(b[1]>>4) | int16(b[2])<<4
 	ci.Center.X = int16(b[3]) | int16(b[4]&0xf)<<8
@@ -143,6 +373,25 @@ func (ci *CalibInfo) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
+// StickParams is the stick parameter block of SPI 0x6086 (left) or 0x6098 (right).
+type StickParams struct {
+	Deadzone   int16
+	RangeRatio int16
+}
+
+// DefaultStickParams is used when the SPI parameter block is blank.
+var DefaultStickParams = StickParams{Deadzone: 0xae}
+
+// UnmarshalBinary ...
+func (sp *StickParams) UnmarshalBinary(b []byte) error {
+	if len(b) < 6 {
+		return &ShortReportError{Length: len(b), Want: 6}
+	}
+	sp.Deadzone = int16(b[3]) | int16(b[4]&0xf)<<8
+	sp.RangeRatio = int16(b[4]>>4) | int16(b[5])<<4
+	return nil
+}
+
 /*
 func (ci *CalibInfo) String() string {
 	max := Stick{ci.Center.X - ci.Max.X, ci.Center.Y - ci.Max.Y}
@@ -188,6 +437,33 @@ func (ir *IRData) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
+// ConnectionEventType ...
+type ConnectionEventType int
+
+const (
+	// Disconnected is sent when the connection to the controller is lost.
+	Disconnected ConnectionEventType = iota + 1
+	// Reconnected is sent when the controller is back and set up again.
+	Reconnected
+)
+
+func (t ConnectionEventType) String() string {
+	switch t {
+	case Disconnected:
+		return "Disconnected"
+	case Reconnected:
+		return "Reconnected"
+	}
+	return "Unknown"
+}
+
+// ConnectionEvent ...
+type ConnectionEvent struct {
+	Type ConnectionEventType
+	Time time.Time
+	Err  error
+}
+
 // Stats ...
 type Stats struct {
 	RumbleCount uint64
@@ -0,0 +1,191 @@
+package joycon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnmarshalSimpleHID(t *testing.T) {
+	// 0x3F reports: buttons(2) hat(1) then four 16-bit axes, high byte significant
+	report := func(b1, b2, hat, lx, ly, rx, ry byte) []byte {
+		return []byte{0x3f, b1, b2, hat, 0x00, lx, 0x00, ly, 0x00, rx, 0x00, ry}
+	}
+	tests := []struct {
+		name    string
+		dt      DeviceType
+		rep     []byte
+		buttons Button
+		hat     Hat
+		left    Stick
+		right   Stick
+	}{
+		{
+			name: "JoyConL idle", dt: JoyConL, rep: report(0x00, 0x00, 0x08, 0x80, 0x80, 0x80, 0x80),
+			hat: HatNeutral, left: Stick{0x800, 0x7f0}, right: Stick{0x800, 0x7f0},
+		},
+		{
+			name: "JoyConL left L minus", dt: JoyConL, rep: report(0x01, 0x41, 0x08, 0x00, 0xff, 0x80, 0x80),
+			buttons: ButtonLeft | ButtonL | ButtonMinus, hat: HatNeutral, left: Stick{0x000, 0x000}, right: Stick{0x800, 0x7f0},
+		},
+		{
+			name: "JoyConL SL SR ZL capture", dt: JoyConL, rep: report(0x30, 0xa0, 0x04, 0x80, 0x80, 0x80, 0x80),
+			buttons: ButtonLeftSL | ButtonLeftSR | ButtonZL | ButtonCapture, hat: HatDown, left: Stick{0x800, 0x7f0}, right: Stick{0x800, 0x7f0},
+		},
+		{
+			name: "JoyConR A SR home", dt: JoyConR, rep: report(0x21, 0x10, 0x08, 0x80, 0x80, 0xff, 0x00),
+			buttons: ButtonA | ButtonRightSR | ButtonHome, hat: HatNeutral, left: Stick{0x800, 0x7f0}, right: Stick{0xff0, 0xff0},
+		},
+		{
+			name: "JoyConR X B Y R ZR plus", dt: JoyConR, rep: report(0x0e, 0xc2, 0x08, 0x80, 0x80, 0x80, 0x80),
+			buttons: ButtonX | ButtonB | ButtonY | ButtonR | ButtonZR | ButtonPlus, hat: HatNeutral, left: Stick{0x800, 0x7f0}, right: Stick{0x800, 0x7f0},
+		},
+		{
+			name: "ProCon A ZR capture hat down right", dt: ProCon, rep: report(0x82, 0x20, 0x03, 0x80, 0x80, 0x80, 0x80),
+			buttons: ButtonA | ButtonZR | ButtonCapture | ButtonDown | ButtonRight, hat: HatDownRight, left: Stick{0x800, 0x7f0}, right: Stick{0x800, 0x7f0},
+		},
+		{
+			name: "ProCon B Y X L R ZL sticks hat up left", dt: ProCon, rep: report(0x7d, 0x0c, 0x07, 0x00, 0x00, 0xff, 0xff),
+			buttons: ButtonB | ButtonY | ButtonX | ButtonL | ButtonR | ButtonZL | ButtonLStick | ButtonRStick | ButtonUp | ButtonLeft, hat: HatUpLeft,
+			left: Stick{0x000, 0xff0}, right: Stick{0xff0, 0x000},
+		},
+		{
+			name: "ProCon hat neutral", dt: ProCon, rep: report(0x00, 0x00, 0x08, 0x80, 0x80, 0x80, 0x80),
+			hat: HatNeutral, left: Stick{0x800, 0x7f0}, right: Stick{0x800, 0x7f0},
+		},
+		{
+			name: "ProCon hat up", dt: ProCon, rep: report(0x00, 0x01, 0xf0, 0x80, 0x80, 0x80, 0x80),
+			buttons: ButtonMinus | ButtonUp, hat: HatUp, left: Stick{0x800, 0x7f0}, right: Stick{0x800, 0x7f0},
+		},
+	}
+	for _, tt := range tests {
+		s := State{Buttons: ButtonHome}
+		if err := s.UnmarshalSimpleHID(tt.rep, tt.dt); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !s.LowResolution {
+			t.Errorf("%s: not LowResolution", tt.name)
+		}
+		if s.Buttons != tt.buttons {
+			t.Errorf("%s: buttons %#x, want %#x", tt.name, s.Buttons, tt.buttons)
+		}
+		if s.Hat != tt.hat {
+			t.Errorf("%s: hat %v, want %v", tt.name, s.Hat, tt.hat)
+		}
+		if s.Left != tt.left || s.Right != tt.right {
+			t.Errorf("%s: sticks %v %v, want %v %v", tt.name, s.Left, s.Right, tt.left, tt.right)
+		}
+	}
+}
+
+func TestUnmarshalSimpleHIDErrors(t *testing.T) {
+	s := State{}
+	if err := s.UnmarshalSimpleHID([]byte{0x3f, 0x00, 0x00, 0x08}, JoyConL); err == nil {
+		t.Error("short report accepted")
+	}
+	if err := s.UnmarshalSimpleHID(make([]byte, 12), DeviceType(0x2017)); err == nil {
+		t.Error("unknown device type accepted")
+	}
+}
+
+func TestStickParamsUnmarshalBinary(t *testing.T) {
+	// factory parameter block of SPI 0x6086
+	b := []byte{0x0f, 0x30, 0x61, 0xae, 0x90, 0xd9, 0xd4, 0x14, 0x54, 0x41, 0x15, 0x54, 0xc7, 0x79, 0x9c, 0x33, 0x36, 0x63}
+	sp := StickParams{}
+	if err := sp.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if sp.Deadzone != 0xae || sp.RangeRatio != 0xd99 {
+		t.Errorf("got %+v", sp)
+	}
+	if err := sp.UnmarshalBinary(b[:5]); err == nil {
+		t.Error("short block accepted")
+	}
+}
+
+func TestStateBattery(t *testing.T) {
+	tests := []struct {
+		b        byte
+		level    BatteryLevel
+		battery  int
+		charging bool
+		joycon   bool
+		grip     bool
+		powered  bool
+	}{
+		{0x8e, BatteryFull, 100, false, true, false, false},
+		{0x9e, BatteryFull, 100, true, true, false, false},
+		{0x41, BatteryLow, 50, false, false, true, true},
+		{0x20, BatteryCritical, 25, false, false, true, false},
+		{0x60, BatteryMedium, 75, false, false, true, false},
+		{0x0e, BatteryEmpty, 0, false, true, false, false},
+		{0xf1, BatteryLevel(0x0e), 100, true, false, true, true},
+		{0xe0, BatteryLevel(0x0e), 100, false, false, true, false},
+	}
+	for _, tt := range tests {
+		rep := make([]byte, 15)
+		rep[0], rep[2] = 0x21, tt.b
+		s := State{}
+		if err := s.UnmarshalBinary(rep); err != nil {
+			t.Fatal(err)
+		}
+		c := s.ConnectionInfo
+		if s.BatteryLevel != tt.level || s.Battery != tt.battery || s.Charging != tt.charging {
+			t.Errorf("%#02x: level %v battery %d charging %v", tt.b, s.BatteryLevel, s.Battery, s.Charging)
+		}
+		if c.JoyCon() != tt.joycon || c.Grip() != tt.grip || c.Powered() != tt.powered {
+			t.Errorf("%#02x: connection %v", tt.b, c)
+		}
+		if s.Battery < 0 || s.Battery > 100 {
+			t.Errorf("%#02x: battery %d", tt.b, s.Battery)
+		}
+	}
+}
+
+func TestDeviceInfoUnmarshalBinary(t *testing.T) {
+	// 0x21 reply to subcommand 0x02 of a right Joy-Con
+	rep := []byte{
+		0x21, 0x05, 0x8e, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x82, 0x02, // ACK, subcommand
+		0x03, 0x8b, 0x02, 0x02, 0x98, 0xb6, 0xe9, 0x4c, 0x5d, 0x6e, 0x01, 0x01,
+	}
+	r := SubcommandReply{}
+	if err := r.UnmarshalBinary(rep); err != nil {
+		t.Fatal(err)
+	}
+	di := DeviceInfo{}
+	if err := di.UnmarshalBinary(r.Data); err != nil {
+		t.Fatal(err)
+	}
+	if di.FirmwareMajor != 0x03 || di.FirmwareMinor != 0x8b || di.Type != JoyConR {
+		t.Errorf("got %+v", di)
+	}
+	if di.MAC.String() != "98:b6:e9:4c:5d:6e" {
+		t.Errorf("mac %v", di.MAC)
+	}
+	// the MAC does not alias the reply
+	r.Data[4] = 0
+	if di.MAC[0] != 0x98 {
+		t.Error("mac aliases the reply data")
+	}
+	for typ, dt := range map[byte]DeviceType{0x01: JoyConL, 0x03: ProCon} {
+		b := append([]byte(nil), rep[15:]...)
+		b[2] = typ
+		if err := di.UnmarshalBinary(b); err != nil || di.Type != dt {
+			t.Errorf("type %#02x: %v %v", typ, di.Type, err)
+		}
+	}
+}
+
+func TestDeviceInfoUnmarshalBinaryErrors(t *testing.T) {
+	di := DeviceInfo{}
+	err := di.UnmarshalBinary([]byte{0x03, 0x8b, 0x05, 0x02, 0x98, 0xb6, 0xe9, 0x4c, 0x5d, 0x6e})
+	var pe *UnknownProductError
+	if !errors.As(err, &pe) || pe.Type != 0x05 {
+		t.Errorf("got %v, want UnknownProductError", err)
+	}
+	var se *ShortReportError
+	if err := di.UnmarshalBinary([]byte{0x03, 0x8b, 0x02, 0x02, 0x98}); !errors.As(err, &se) || se.Length != 5 || se.Want != 10 {
+		t.Errorf("got %v, want ShortReportError", err)
+	}
+}
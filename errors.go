@@ -0,0 +1,65 @@
+package joycon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned by Search when no controller matches.
+	ErrNotFound = errors.New("not found device")
+	// ErrClosed is returned when the Joycon or its connection is closed.
+	ErrClosed = errors.New("joycon closed")
+	// ErrTimeout is returned when the controller does not answer in time.
+	ErrTimeout = errors.New("timeout")
+	// ErrUnsupported is returned for features the controller type lacks.
+	ErrUnsupported = errors.New("not supported by this controller")
+)
+
+// timeoutError is ErrTimeout caused by a context deadline,
+// it matches both ErrTimeout and context.DeadlineExceeded.
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return ErrTimeout.Error() }
+
+func (timeoutError) Is(target error) bool {
+	return target == ErrTimeout || target == context.DeadlineExceeded
+}
+
+// UnknownBackendError is returned for a backend name that is not registered.
+type UnknownBackendError struct {
+	Name string
+}
+
+func (e *UnknownBackendError) Error() string {
+	return fmt.Sprintf("unknown backend: %s", e.Name)
+}
+
+// UnknownProductError is returned by NewJoycon for an unknown SPI product type.
+type UnknownProductError struct {
+	Type byte
+}
+
+func (e *UnknownProductError) Error() string {
+	return fmt.Sprintf("unknown product type: %d", e.Type)
+}
+
+// ShortReportError is returned when a report is shorter than its format needs.
+type ShortReportError struct {
+	Length int
+	Want   int
+}
+
+func (e *ShortReportError) Error() string {
+	return fmt.Sprintf("invalid bytes length: %d < %d", e.Length, e.Want)
+}
+
+// SubcommandNACKError is returned when the controller rejects a subcommand.
+type SubcommandNACKError struct {
+	ID byte
+}
+
+func (e *SubcommandNACKError) Error() string {
+	return fmt.Sprintf("subcommand %#02x not acknowledged", e.ID)
+}
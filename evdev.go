@@ -0,0 +1,313 @@
+package joycon
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Linux input event constants used by the hid-nintendo kernel driver.
+const (
+	evSyn = 0x00
+	evKey = 0x01
+	evAbs = 0x03
+
+	synReport = 0x00
+
+	absX     = 0x00
+	absY     = 0x01
+	absZ     = 0x02
+	absRX    = 0x03
+	absRY    = 0x04
+	absRZ    = 0x05
+	absHat0X = 0x10
+	absHat0Y = 0x11
+
+	// absolute stick range reported by hid-nintendo
+	evdevStickMax = 32767
+	// fallback IMU resolutions of hid-nintendo (units per G, units per dps)
+	evdevAccelRes = 4096
+	evdevGyroRes  = 14247
+)
+
+// inputEventSize is sizeof(struct input_event): timeval(2 longs), type, code, value.
+const inputEventSize = 2*strconv.IntSize/8 + 8
+
+// evdev key code -> State.Buttons
+var (
+	evdevCommonButtons = map[uint16]Button{
+		0x13a: ButtonMinus,   // BTN_SELECT
+		0x13b: ButtonPlus,    // BTN_START
+		0x13e: ButtonRStick,  // BTN_THUMBR
+		0x13d: ButtonLStick,  // BTN_THUMBL
+		0x13c: ButtonHome,    // BTN_MODE
+		0x135: ButtonCapture, // BTN_Z
+		0x221: ButtonDown,    // BTN_DPAD_DOWN
+		0x220: ButtonUp,      // BTN_DPAD_UP
+		0x223: ButtonRight,   // BTN_DPAD_RIGHT
+		0x222: ButtonLeft,    // BTN_DPAD_LEFT
+	}
+	evdevButtons = map[DeviceType]map[uint16]Button{
+		JoyConL: {
+			0x137: ButtonLeftSL, // BTN_TR
+			0x139: ButtonLeftSR, // BTN_TR2
+			0x136: ButtonL,      // BTN_TL
+			0x138: ButtonZL,     // BTN_TL2
+		},
+		JoyConR: {
+			0x134: ButtonY,       // BTN_WEST
+			0x133: ButtonX,       // BTN_NORTH
+			0x130: ButtonB,       // BTN_SOUTH
+			0x131: ButtonA,       // BTN_EAST
+			0x138: ButtonRightSR, // BTN_TL2
+			0x136: ButtonRightSL, // BTN_TL
+			0x137: ButtonR,       // BTN_TR
+			0x139: ButtonZR,      // BTN_TR2
+		},
+		ProCon: {
+			0x134: ButtonY,  // BTN_WEST
+			0x133: ButtonX,  // BTN_NORTH
+			0x130: ButtonB,  // BTN_SOUTH
+			0x131: ButtonA,  // BTN_EAST
+			0x137: ButtonR,  // BTN_TR
+			0x139: ButtonZR, // BTN_TR2
+			0x136: ButtonL,  // BTN_TL
+			0x138: ButtonZL, // BTN_TL2
+		},
+	}
+)
+
+// inputEvent is a decoded struct input_event.
+type inputEvent struct {
+	Time  time.Time
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// UnmarshalBinary ...
+func (ev *inputEvent) UnmarshalBinary(b []byte) error {
+	if len(b) < inputEventSize {
+		return io.ErrUnexpectedEOF
+	}
+	var sec, usec int64
+	if strconv.IntSize == 64 {
+		sec = int64(binary.LittleEndian.Uint64(b[0:8]))
+		usec = int64(binary.LittleEndian.Uint64(b[8:16]))
+	} else {
+		sec = int64(int32(binary.LittleEndian.Uint32(b[0:4])))
+		usec = int64(int32(binary.LittleEndian.Uint32(b[4:8])))
+	}
+	b = b[inputEventSize-8:]
+	ev.Time = time.Unix(sec, usec*1000)
+	ev.Type = binary.LittleEndian.Uint16(b[0:2])
+	ev.Code = binary.LittleEndian.Uint16(b[2:4])
+	ev.Value = int32(binary.LittleEndian.Uint32(b[4:8]))
+	return nil
+}
+
+// evdevStateDecoder builds State from the buttons/sticks event node.
+type evdevStateDecoder struct {
+	buttons map[uint16]Button
+	state   State
+}
+
+func newEvdevStateDecoder(dt DeviceType) *evdevStateDecoder {
+	buttons := map[uint16]Button{}
+	for k, v := range evdevCommonButtons {
+		buttons[k] = v
+	}
+	for k, v := range evdevButtons[dt] {
+		buttons[k] = v
+	}
+	return &evdevStateDecoder{buttons: buttons}
+}
+
+// feed returns the accumulated State on every SYN_REPORT.
+func (d *evdevStateDecoder) feed(ev inputEvent) (State, bool) {
+	switch ev.Type {
+	case evSyn:
+		if ev.Code == synReport {
+			s := d.state
+			s.Time = ev.Time
+			d.state.Tick++
+			return s, true
+		}
+	case evKey:
+		if button, ok := d.buttons[ev.Code]; ok {
+			if ev.Value != 0 {
+				d.state.Buttons |= button
+			} else {
+				d.state.Buttons &^= button
+			}
+		}
+	case evAbs:
+		v := float32(ev.Value) / evdevStickMax
+		switch ev.Code {
+		case absX:
+			d.state.LeftAdj.X = v
+		case absY:
+			// the driver reports up as negative
+			d.state.LeftAdj.Y = -v
+		case absRX:
+			d.state.RightAdj.X = v
+		case absRY:
+			d.state.RightAdj.Y = -v
+		case absHat0X:
+			d.state.Buttons &^= ButtonRight | ButtonLeft
+			switch {
+			case ev.Value > 0:
+				d.state.Buttons |= ButtonRight
+			case ev.Value < 0:
+				d.state.Buttons |= ButtonLeft
+			}
+		case absHat0Y:
+			d.state.Buttons &^= ButtonDown | ButtonUp
+			switch {
+			case ev.Value > 0:
+				d.state.Buttons |= ButtonDown
+			case ev.Value < 0:
+				d.state.Buttons |= ButtonUp
+			}
+		}
+	}
+	return State{}, false
+}
+
+// evdevSensorDecoder builds Sensor from the IMU event node.
+type evdevSensorDecoder struct {
+	accelRes float32
+	gyroRes  float32
+	sensor   Sensor
+}
+
+// feed returns the accumulated Sensor on every SYN_REPORT.
+func (d *evdevSensorDecoder) feed(ev inputEvent) (Sensor, bool) {
+	switch ev.Type {
+	case evSyn:
+		if ev.Code == synReport {
+			s := d.sensor
+			d.sensor.Tick++
+			return s, true
+		}
+	case evAbs:
+		switch ev.Code {
+		case absX:
+			d.sensor.Accel.X = float32(ev.Value) / d.accelRes
+		case absY:
+			d.sensor.Accel.Y = float32(ev.Value) / d.accelRes
+		case absZ:
+			d.sensor.Accel.Z = float32(ev.Value) / d.accelRes
+		case absRX:
+			d.sensor.Gyro.X = float32(ev.Value) / d.gyroRes
+		case absRY:
+			d.sensor.Gyro.Y = float32(ev.Value) / d.gyroRes
+		case absRZ:
+			d.sensor.Gyro.Z = float32(ev.Value) / d.gyroRes
+		}
+	}
+	return Sensor{}, false
+}
+
+// readInputEvents calls fn for every input_event read from r.
+func readInputEvents(r io.Reader, fn func(inputEvent)) error {
+	buf := make([]byte, inputEventSize*64)
+	rest := 0
+	for {
+		n, err := io.ReadAtLeast(r, buf[rest:], inputEventSize-rest)
+		if err != nil {
+			return err
+		}
+		n += rest
+		i := 0
+		for ; i+inputEventSize <= n; i += inputEventSize {
+			var ev inputEvent
+			ev.UnmarshalBinary(buf[i : i+inputEventSize])
+			fn(ev)
+		}
+		// keep a partial event for the next read
+		rest = copy(buf, buf[i:n])
+	}
+}
+
+// Evdev is a controller bound to the hid-nintendo kernel driver, read through
+// its evdev nodes. Stick values are already calibrated by the driver, so only
+// LeftAdj/RightAdj are filled in State.
+type Evdev struct {
+	dt        DeviceType
+	state     chan State
+	sensor    chan Sensor
+	closers   []io.Closer
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+	stats     Stats
+}
+
+// State ...
+func (e *Evdev) State() <-chan State {
+	return e.state
+}
+
+// Sensor ...
+func (e *Evdev) Sensor() <-chan Sensor {
+	return e.sensor
+}
+
+// Type ...
+func (e *Evdev) Type() DeviceType {
+	return e.dt
+}
+
+// Stats ...
+func (e *Evdev) Stats() Stats {
+	return Stats{
+		SensorCount: atomic.LoadUint64(&e.stats.SensorCount),
+		StateCount:  atomic.LoadUint64(&e.stats.StateCount),
+	}
+}
+
+// Close ...
+func (e *Evdev) Close() {
+	e.closeOnce.Do(func() {
+		for _, c := range e.closers {
+			c.Close()
+		}
+		e.wg.Wait()
+	})
+}
+
+func (e *Evdev) readState(r io.Reader) {
+	defer e.wg.Done()
+	defer close(e.state)
+	d := newEvdevStateDecoder(e.dt)
+	edges := buttonEdges{}
+	readInputEvents(r, func(ev inputEvent) {
+		if s, ok := d.feed(ev); ok {
+			edges.add(&s)
+			select {
+			case e.state <- s:
+				atomic.AddUint64(&e.stats.StateCount, 1)
+				edges.delivered()
+			default:
+			}
+		}
+	})
+}
+
+func (e *Evdev) readSensor(r io.Reader, accelRes, gyroRes float32) {
+	defer e.wg.Done()
+	defer close(e.sensor)
+	d := &evdevSensorDecoder{accelRes: accelRes, gyroRes: gyroRes}
+	readInputEvents(r, func(ev inputEvent) {
+		if s, ok := d.feed(ev); ok {
+			select {
+			case e.sensor <- s:
+				atomic.AddUint64(&e.stats.SensorCount, 1)
+			default:
+			}
+		}
+	})
+}
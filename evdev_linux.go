@@ -0,0 +1,136 @@
+package joycon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/flynn/hid"
+)
+
+// inputPropAccelerometer is INPUT_PROP_ACCELEROMETER, set on the IMU node.
+const inputPropAccelerometer = 0x06
+
+// KernelDriverBound reports whether the hid-nintendo kernel driver has
+// claimed the device, in which case NewEvdev should be used instead of NewJoycon.
+// Only hidraw device paths can be resolved.
+func KernelDriverBound(info *hid.DeviceInfo) bool {
+	name, ok := hidrawName(info.Path)
+	if !ok {
+		return false
+	}
+	return defaultHidraw.driver(name) == "nintendo"
+}
+
+// NewEvdev opens the evdev nodes that hid-nintendo created for the device.
+func NewEvdev(info *hid.DeviceInfo) (*Evdev, error) {
+	name, ok := hidrawName(info.Path)
+	if !ok {
+		return nil, errors.New("not a hidraw device path: " + info.Path)
+	}
+	buttons, imu, err := defaultHidraw.eventNodes(name)
+	if err != nil {
+		return nil, err
+	}
+	e := &Evdev{
+		dt:     DeviceType(info.ProductID),
+		state:  make(chan State, 16),
+		sensor: make(chan Sensor, 16),
+	}
+	bf, err := os.Open(buttons)
+	if err != nil {
+		return nil, err
+	}
+	e.closers = append(e.closers, bf)
+	e.wg.Add(1)
+	go e.readState(bf)
+	if imu == "" {
+		close(e.sensor)
+		return e, nil
+	}
+	sf, err := os.Open(imu)
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	e.closers = append(e.closers, sf)
+	accelRes, gyroRes := float32(evdevAccelRes), float32(evdevGyroRes)
+	if res, err := absResolution(sf, absX); err == nil && res > 0 {
+		accelRes = float32(res)
+	}
+	if res, err := absResolution(sf, absRX); err == nil && res > 0 {
+		gyroRes = float32(res)
+	}
+	e.wg.Add(1)
+	go e.readSensor(sf, accelRes, gyroRes)
+	return e, nil
+}
+
+// hidrawName returns "hidrawN" for "hidraw:/dev/hidrawN" or "/dev/hidrawN".
+func hidrawName(path string) (string, bool) {
+	name := filepath.Base(strings.TrimPrefix(path, "hidraw:"))
+	return name, strings.HasPrefix(name, "hidraw")
+}
+
+// driver returns the kernel driver bound to the HID device behind a hidraw node.
+func (b *hidrawBackend) driver(name string) string {
+	link, err := os.Readlink(filepath.Join(b.sysfs, "class", "hidraw", name, "device", "driver"))
+	if err == nil {
+		return filepath.Base(link)
+	}
+	uevent, err := readUevent(filepath.Join(b.sysfs, "class", "hidraw", name, "device", "uevent"))
+	if err != nil {
+		return ""
+	}
+	return uevent["DRIVER"]
+}
+
+// eventNodes finds the buttons/sticks and IMU event nodes of the HID device behind a hidraw node.
+func (b *hidrawBackend) eventNodes(name string) (buttons, imu string, err error) {
+	inputs, err := filepath.Glob(filepath.Join(b.sysfs, "class", "hidraw", name, "device", "input", "input*"))
+	if err != nil {
+		return "", "", err
+	}
+	for _, input := range inputs {
+		events, _ := filepath.Glob(filepath.Join(input, "event*"))
+		if len(events) == 0 {
+			continue
+		}
+		node := filepath.Join(b.dev, "input", filepath.Base(events[0]))
+		props, _ := os.ReadFile(filepath.Join(input, "properties"))
+		p, _ := strconv.ParseUint(strings.TrimSpace(string(props)), 16, 64)
+		if p&(1<<inputPropAccelerometer) != 0 {
+			imu = node
+		} else {
+			buttons = node
+		}
+	}
+	if buttons == "" {
+		return "", "", errors.New("no evdev node for " + name)
+	}
+	return buttons, imu, nil
+}
+
+// absResolution reads input_absinfo.resolution with EVIOCGABS.
+func absResolution(f *os.File, code uint16) (int32, error) {
+	var absinfo [6]int32 // value, minimum, maximum, fuzz, flat, resolution
+	req := uintptr(2<<30 | unsafe.Sizeof(absinfo)<<16 | 'E'<<8 | (0x40 + uintptr(code)))
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var errno syscall.Errno
+	if err := rc.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(&absinfo)))
+	}); err != nil {
+		return 0, err
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return absinfo[5], nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package joycon
+
+import (
+	"errors"
+
+	"github.com/flynn/hid"
+)
+
+// KernelDriverBound ...
+func KernelDriverBound(info *hid.DeviceInfo) bool {
+	return false
+}
+
+// NewEvdev ...
+func NewEvdev(info *hid.DeviceInfo) (*Evdev, error) {
+	return nil, errors.New("evdev is only available on linux")
+}
@@ -0,0 +1,191 @@
+package joycon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// encodeEvent encodes a struct input_event of the running platform.
+func encodeEvent(t time.Time, typ, code uint16, value int32) []byte {
+	b := make([]byte, inputEventSize)
+	if strconv.IntSize == 64 {
+		binary.LittleEndian.PutUint64(b[0:8], uint64(t.Unix()))
+		binary.LittleEndian.PutUint64(b[8:16], uint64(t.Nanosecond()/1000))
+	} else {
+		binary.LittleEndian.PutUint32(b[0:4], uint32(t.Unix()))
+		binary.LittleEndian.PutUint32(b[4:8], uint32(t.Nanosecond()/1000))
+	}
+	p := inputEventSize - 8
+	binary.LittleEndian.PutUint16(b[p:p+2], typ)
+	binary.LittleEndian.PutUint16(b[p+2:p+4], code)
+	binary.LittleEndian.PutUint32(b[p+4:p+8], uint32(value))
+	return b
+}
+
+type testEvent struct {
+	ms    int
+	typ   uint16
+	code  uint16
+	value int32
+}
+
+func encodeEvents(t0 time.Time, evs []testEvent) []byte {
+	var buf bytes.Buffer
+	for _, ev := range evs {
+		buf.Write(encodeEvent(t0.Add(time.Duration(ev.ms)*time.Millisecond), ev.typ, ev.code, ev.value))
+	}
+	return buf.Bytes()
+}
+
+func decodeStates(t *testing.T, dt DeviceType, r io.Reader) []State {
+	d := newEvdevStateDecoder(dt)
+	var res []State
+	err := readInputEvents(r, func(ev inputEvent) {
+		if s, ok := d.feed(ev); ok {
+			res = append(res, s)
+		}
+	})
+	if err != io.EOF {
+		t.Fatalf("got %v, want io.EOF", err)
+	}
+	return res
+}
+
+func TestEvdevStateDecoder(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	stream := encodeEvents(t0, []testEvent{
+		{0, evKey, 0x131, 1},             // BTN_EAST: A
+		{0, evKey, 0x13c, 1},             // BTN_MODE: Home
+		{0, evAbs, absX, evdevStickMax},  // left stick right
+		{0, evAbs, absY, -evdevStickMax}, // left stick up
+		{0, evSyn, synReport, 0},
+		{15, evKey, 0x13c, 0},
+		{15, evAbs, absHat0X, -1},
+		{15, evAbs, absHat0Y, 1},
+		{15, evAbs, absRY, evdevStickMax},
+		{15, evSyn, synReport, 0},
+		{30, evAbs, absHat0X, 0},
+		{30, evAbs, absHat0Y, -1},
+		{30, evKey, 0x137, 1}, // BTN_TR: R
+		{30, evSyn, 0x02, 0},  // SYN_MT_REPORT is not a frame
+		{30, evSyn, synReport, 0},
+	})
+	want := []struct {
+		buttons Button
+		left    Vec2
+		right   Vec2
+		ms      int
+	}{
+		{ButtonA | ButtonHome, Vec2{1, 1}, Vec2{}, 0},
+		{ButtonA | ButtonLeft | ButtonDown, Vec2{1, 1}, Vec2{0, -1}, 15},
+		{ButtonA | ButtonUp | ButtonR, Vec2{1, 1}, Vec2{0, -1}, 30},
+	}
+	for _, r := range []struct {
+		name string
+		r    io.Reader
+	}{
+		{"whole", bytes.NewReader(stream)},
+		{"one byte reads", iotest.OneByteReader(bytes.NewReader(stream))},
+		{"half reads", iotest.HalfReader(bytes.NewReader(stream))},
+	} {
+		got := decodeStates(t, ProCon, r.r)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d states, want %d", r.name, len(got), len(want))
+		}
+		for i, w := range want {
+			s := got[i]
+			if s.Buttons != w.buttons || s.LeftAdj != w.left || s.RightAdj != w.right {
+				t.Errorf("%s: state %d: got %v %v %v, want %v %v %v",
+					r.name, i, s.Buttons, s.LeftAdj, s.RightAdj, w.buttons, w.left, w.right)
+			}
+			if !s.Time.Equal(t0.Add(time.Duration(w.ms) * time.Millisecond)) {
+				t.Errorf("%s: state %d: time %v", r.name, i, s.Time)
+			}
+			if s.Tick != byte(i) {
+				t.Errorf("%s: state %d: tick %d", r.name, i, s.Tick)
+			}
+		}
+	}
+}
+
+func TestEvdevStateDecoderLayouts(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	stream := encodeEvents(t0, []testEvent{
+		{0, evKey, 0x136, 1}, // BTN_TL
+		{0, evKey, 0x138, 1}, // BTN_TL2
+		{0, evSyn, synReport, 0},
+	})
+	for _, tt := range []struct {
+		dt   DeviceType
+		want Button
+	}{
+		{JoyConL, ButtonL | ButtonZL},
+		{JoyConR, ButtonRightSL | ButtonRightSR},
+		{ProCon, ButtonL | ButtonZL},
+	} {
+		got := decodeStates(t, tt.dt, bytes.NewReader(stream))
+		if len(got) != 1 || got[0].Buttons != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.dt, got, tt.want)
+		}
+	}
+}
+
+func TestEvdevSensorDecoder(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	stream := encodeEvents(t0, []testEvent{
+		{0, evAbs, absX, 4096},
+		{0, evAbs, absY, -2048},
+		{0, evAbs, absZ, 0},
+		{0, evAbs, absRX, 14247},
+		{0, evAbs, absRY, -14247 * 2},
+		{0, evAbs, absRZ, 0},
+		{0, evSyn, synReport, 0},
+		{5, evAbs, absZ, 8192},
+		{5, evSyn, synReport, 0},
+	})
+	for _, res := range []struct {
+		accel, gyro float32
+		scale       float32
+	}{
+		{evdevAccelRes, evdevGyroRes, 1},
+		{evdevAccelRes * 2, evdevGyroRes * 2, 0.5},
+	} {
+		d := &evdevSensorDecoder{accelRes: res.accel, gyroRes: res.gyro}
+		var got []Sensor
+		readInputEvents(bytes.NewReader(stream), func(ev inputEvent) {
+			if s, ok := d.feed(ev); ok {
+				got = append(got, s)
+			}
+		})
+		k := res.scale
+		want := []Sensor{
+			{Tick: 0, Accel: Vec3{1 * k, -0.5 * k, 0}, Gyro: Vec3{1 * k, -2 * k, 0}},
+			{Tick: 1, Accel: Vec3{1 * k, -0.5 * k, 2 * k}, Gyro: Vec3{1 * k, -2 * k, 0}},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %d sensors, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("resolution %v/%v: sensor %d: got %+v, want %+v", res.accel, res.gyro, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestInputEventShort(t *testing.T) {
+	var ev inputEvent
+	if err := ev.UnmarshalBinary(make([]byte, inputEventSize-1)); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v", err)
+	}
+	if err := readInputEvents(bytes.NewReader(make([]byte, inputEventSize-1)), func(inputEvent) {
+		t.Error("unexpected event")
+	}); err == nil {
+		t.Error("expected an error for a truncated stream")
+	}
+}
@@ -0,0 +1,94 @@
+package joycon
+
+import (
+	"time"
+)
+
+// ButtonEventType ...
+type ButtonEventType int
+
+// ButtonEventType values.
+const (
+	ButtonEventDown ButtonEventType = iota
+	ButtonEventUp
+)
+
+func (t ButtonEventType) String() string {
+	switch t {
+	case ButtonEventDown:
+		return "Down"
+	case ButtonEventUp:
+		return "Up"
+	}
+	return "Unknown"
+}
+
+// ButtonEvent is a single button edge.
+type ButtonEvent struct {
+	Type   ButtonEventType
+	Button Button
+	Time   time.Time
+	// Held is how long the button was held, set on ButtonEventUp.
+	Held time.Duration
+}
+
+// ButtonDecoder turns a State stream into ButtonEvents.
+// It uses State.Down and State.Up, so presses between dropped States are not lost.
+// The zero value is ready to use.
+type ButtonDecoder struct {
+	held  Button
+	since map[Button]time.Time
+}
+
+// Decode returns the events of s in bit order, nil for error States.
+func (d *ButtonDecoder) Decode(s State) []ButtonEvent {
+	if s.Err != nil {
+		return nil
+	}
+	if d.since == nil {
+		d.since = map[Button]time.Time{}
+	}
+	t := s.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	down := s.Down | s.Buttons&^d.held
+	up := s.Up | d.held&^s.Buttons
+	var res []ButtonEvent
+	(down | up).Each(func(b Button) {
+		held := d.held&b != 0
+		if held && up&b != 0 {
+			res = append(res, ButtonEvent{Type: ButtonEventUp, Button: b, Time: t, Held: t.Sub(d.since[b])})
+			held = false
+		}
+		if !held && down&b != 0 {
+			res = append(res, ButtonEvent{Type: ButtonEventDown, Button: b, Time: t})
+			d.since[b] = t
+			held = true
+		}
+		if held && s.Buttons&b == 0 {
+			// pressed again and released between two delivered States
+			res = append(res, ButtonEvent{Type: ButtonEventUp, Button: b, Time: t, Held: t.Sub(d.since[b])})
+		}
+	})
+	d.held = s.Buttons
+	return res
+}
+
+// buttonEdges accumulates the button changes of States dropped before delivery.
+type buttonEdges struct {
+	last, down, up Button
+}
+
+// add sets s.Down and s.Up to the changes since the last delivered State.
+func (e *buttonEdges) add(s *State) {
+	e.down |= s.Buttons &^ e.last
+	e.up |= e.last &^ s.Buttons
+	e.last = s.Buttons
+	s.Down, s.Up = e.down, e.up
+}
+
+// delivered ...
+func (e *buttonEdges) delivered() {
+	e.down, e.up = 0, 0
+}
@@ -0,0 +1,92 @@
+package joycon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func formatEvents(evs []ButtonEvent, t0 time.Time) string {
+	res := []string{}
+	for _, ev := range evs {
+		res = append(res, fmt.Sprintf("%v %v@%d/%d", ev.Type, ev.Button,
+			ev.Time.Sub(t0)/time.Millisecond, ev.Held/time.Millisecond))
+	}
+	return strings.Join(res, ", ")
+}
+
+func TestButtonDecoderDrops(t *testing.T) {
+	t0 := time.Unix(100, 0)
+	type step struct {
+		buttons Button
+		ms      int
+		deliver bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		want  []string // events of each delivered State
+	}{
+		{
+			name:  "press release",
+			steps: []step{{ButtonA, 0, true}, {0, 30, true}},
+			want:  []string{"Down A@0/0", "Up A@30/30"},
+		},
+		{
+			name:  "press release dropped",
+			steps: []step{{ButtonA, 10, false}, {0, 20, true}},
+			want:  []string{"Down A@20/0, Up A@20/0"},
+		},
+		{
+			name:  "release press dropped",
+			steps: []step{{ButtonA, 0, true}, {0, 10, false}, {ButtonA, 20, true}},
+			want:  []string{"Down A@0/0", "Up A@20/20, Down A@20/0"},
+		},
+		{
+			name: "release press release dropped",
+			steps: []step{
+				{ButtonA, 0, true}, {0, 10, false}, {ButtonA, 20, false}, {0, 30, true},
+				{ButtonA, 40, true},
+			},
+			want: []string{"Down A@0/0", "Up A@30/30, Down A@30/0, Up A@30/0", "Down A@40/0"},
+		},
+		{
+			name: "other button held",
+			steps: []step{
+				{ButtonB, 0, true}, {ButtonA | ButtonB, 10, false}, {ButtonB, 20, true}, {0, 50, true},
+			},
+			want: []string{"Down B@0/0", "Down A@20/0, Up A@20/0", "Up B@50/50"},
+		},
+	}
+	for _, tt := range tests {
+		e := buttonEdges{}
+		d := ButtonDecoder{}
+		got := []string{}
+		for _, st := range tt.steps {
+			s := State{Buttons: st.buttons, Time: t0.Add(time.Duration(st.ms) * time.Millisecond)}
+			e.add(&s)
+			if !st.deliver {
+				continue
+			}
+			e.delivered()
+			got = append(got, formatEvents(d.Decode(s), t0))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestButtonDecoderWithoutEdges(t *testing.T) {
+	d := ButtonDecoder{}
+	if evs := d.Decode(State{Buttons: ButtonX}); len(evs) != 1 || evs[0].Type != ButtonEventDown {
+		t.Fatalf("got %v", evs)
+	}
+	if evs := d.Decode(State{Err: ErrClosed}); evs != nil {
+		t.Fatalf("got %v for error State", evs)
+	}
+	if evs := d.Decode(State{}); len(evs) != 1 || evs[0].Type != ButtonEventUp {
+		t.Fatalf("got %v", evs)
+	}
+}
@@ -0,0 +1,198 @@
+package joycon
+
+import (
+	"time"
+)
+
+// GestureType ...
+type GestureType int
+
+// GestureType values.
+const (
+	GestureTap GestureType = iota
+	GestureDoubleTap
+	GestureMultiTap
+	GestureLongPress
+	GestureRepeat
+)
+
+func (t GestureType) String() string {
+	switch t {
+	case GestureTap:
+		return "Tap"
+	case GestureDoubleTap:
+		return "DoubleTap"
+	case GestureMultiTap:
+		return "MultiTap"
+	case GestureLongPress:
+		return "LongPress"
+	case GestureRepeat:
+		return "Repeat"
+	}
+	return "Unknown"
+}
+
+// Gesture is a recognized button gesture.
+// Count is the number of taps, or of repeats so far for GestureRepeat.
+type Gesture struct {
+	Type   GestureType
+	Button Button
+	Time   time.Time
+	Count  int
+}
+
+// GestureConfig holds the thresholds of a button, zero durations disable a gesture.
+type GestureConfig struct {
+	// LongPress is the hold time of GestureLongPress.
+	LongPress time.Duration
+	// TapWindow is the time after a release to wait for the next tap.
+	// Taps are reported at once when it is zero.
+	TapWindow time.Duration
+	// MaxTaps reports the taps as soon as this count is reached, no limit when zero.
+	MaxTaps int
+	// RepeatDelay is the hold time of the first GestureRepeat,
+	// RepeatInterval the time between the next ones (RepeatDelay when zero).
+	RepeatDelay    time.Duration
+	RepeatInterval time.Duration
+}
+
+// DefaultGestureConfig ...
+var DefaultGestureConfig = GestureConfig{
+	LongPress: 500 * time.Millisecond,
+	TapWindow: 250 * time.Millisecond,
+}
+
+type gestureState struct {
+	held     bool
+	since    time.Time // last press
+	released time.Time // last release
+	taps     int
+	long     bool
+	repeats  int
+	next     time.Time // next repeat
+}
+
+// Recognizer detects tap, multi-tap, long-press and repeat gestures from States.
+// It keeps no clock of its own: time comes from State.Time in Update and from Tick,
+// so a test can drive it with scripted States and a synthetic clock.
+type Recognizer struct {
+	def     GestureConfig
+	configs map[Button]GestureConfig
+	dec     ButtonDecoder
+	states  map[Button]*gestureState
+}
+
+// NewRecognizer returns a Recognizer using def for buttons not configured by Configure.
+func NewRecognizer(def GestureConfig) *Recognizer {
+	return &Recognizer{
+		def:     def,
+		configs: map[Button]GestureConfig{},
+		states:  map[Button]*gestureState{},
+	}
+}
+
+// Configure sets the thresholds of the buttons of b.
+func (r *Recognizer) Configure(b Button, c GestureConfig) {
+	b.Each(func(x Button) {
+		r.configs[x] = c
+	})
+}
+
+func (r *Recognizer) config(b Button) GestureConfig {
+	if c, ok := r.configs[b]; ok {
+		return c
+	}
+	return r.def
+}
+
+func (r *Recognizer) state(b Button) *gestureState {
+	st, ok := r.states[b]
+	if !ok {
+		st = &gestureState{}
+		r.states[b] = st
+	}
+	return st
+}
+
+// Update feeds a State and returns the gestures completed up to s.Time.
+func (r *Recognizer) Update(s State) []Gesture {
+	events := r.dec.Decode(s)
+	if len(events) == 0 {
+		if s.Time.IsZero() {
+			return nil
+		}
+		return r.Tick(s.Time)
+	}
+	res := r.Tick(events[0].Time)
+	for _, ev := range events {
+		c, st := r.config(ev.Button), r.state(ev.Button)
+		switch ev.Type {
+		case ButtonEventDown:
+			st.held, st.since, st.long, st.repeats = true, ev.Time, false, 0
+			st.next = ev.Time.Add(c.RepeatDelay)
+		case ButtonEventUp:
+			st.held = false
+			if st.long || st.repeats > 0 {
+				continue
+			}
+			st.taps++
+			st.released = ev.Time
+			if c.TapWindow == 0 || (c.MaxTaps > 0 && st.taps >= c.MaxTaps) {
+				res = append(res, r.taps(ev.Button, st, ev.Time))
+			}
+		}
+	}
+	return res
+}
+
+// Tick returns the gestures whose thresholds passed at now:
+// expired tap windows, long presses and repeats. Gesture.Time is the
+// threshold time, not now. Call it periodically while no State arrives.
+func (r *Recognizer) Tick(now time.Time) []Gesture {
+	var res []Gesture
+	for _, b := range AllButtons {
+		st, ok := r.states[b]
+		if !ok {
+			continue
+		}
+		c := r.config(b)
+		if !st.held {
+			if t := st.released.Add(c.TapWindow); st.taps > 0 && !now.Before(t) {
+				res = append(res, r.taps(b, st, t))
+			}
+			continue
+		}
+		if t := st.since.Add(c.LongPress); c.LongPress > 0 && !st.long && !now.Before(t) {
+			if st.taps > 0 {
+				res = append(res, r.taps(b, st, t))
+			}
+			st.long = true
+			res = append(res, Gesture{Type: GestureLongPress, Button: b, Time: t, Count: 1})
+		}
+		if c.RepeatDelay > 0 {
+			interval := c.RepeatInterval
+			if interval <= 0 {
+				interval = c.RepeatDelay
+			}
+			for !now.Before(st.next) {
+				st.repeats++
+				res = append(res, Gesture{Type: GestureRepeat, Button: b, Time: st.next, Count: st.repeats})
+				st.next = st.next.Add(interval)
+			}
+		}
+	}
+	return res
+}
+
+// taps reports and clears the pending taps of a button.
+func (r *Recognizer) taps(b Button, st *gestureState, t time.Time) Gesture {
+	g := Gesture{Type: GestureMultiTap, Button: b, Time: t, Count: st.taps}
+	switch st.taps {
+	case 1:
+		g.Type = GestureTap
+	case 2:
+		g.Type = GestureDoubleTap
+	}
+	st.taps = 0
+	return g
+}
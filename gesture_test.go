@@ -0,0 +1,159 @@
+package joycon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecognizer(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	// step is a State (buttons at ms) or, when tick is set, a Tick at ms
+	type step struct {
+		ms      int
+		buttons Button
+		tick    bool
+	}
+	press := func(ms int, b Button) step { return step{ms: ms, buttons: b} }
+	release := func(ms int) step { return step{ms: ms} }
+	tick := func(ms int) step { return step{ms: ms, tick: true} }
+	tests := []struct {
+		name   string
+		config GestureConfig
+		steps  []step
+		want   string
+	}{
+		{
+			name:   "tap after the window",
+			config: DefaultGestureConfig,
+			steps:  []step{press(0, ButtonA), release(100), tick(349), tick(400)},
+			want:   "A Tap 1@350",
+		},
+		{
+			name:   "tap at once without window",
+			config: GestureConfig{LongPress: 500 * time.Millisecond},
+			steps:  []step{press(0, ButtonA), release(100)},
+			want:   "A Tap 1@100",
+		},
+		{
+			name:   "double tap",
+			config: DefaultGestureConfig,
+			steps:  []step{press(0, ButtonA), release(50), press(200, ButtonA), release(250), tick(1000)},
+			want:   "A DoubleTap 2@500",
+		},
+		{
+			name:   "taps too far apart",
+			config: DefaultGestureConfig,
+			steps:  []step{press(0, ButtonA), release(50), press(400, ButtonA), release(450), tick(1000)},
+			want:   "A Tap 1@300, A Tap 1@700",
+		},
+		{
+			name:   "multi tap",
+			config: DefaultGestureConfig,
+			steps: []step{
+				press(0, ButtonA), release(50), press(100, ButtonA), release(150),
+				press(200, ButtonA), release(250), tick(1000),
+			},
+			want: "A MultiTap 3@500",
+		},
+		{
+			name:   "max taps",
+			config: GestureConfig{TapWindow: 250 * time.Millisecond, MaxTaps: 2},
+			steps: []step{
+				press(0, ButtonA), release(50), press(100, ButtonA), release(150),
+				press(200, ButtonA), release(250), tick(1000),
+			},
+			want: "A DoubleTap 2@150, A Tap 1@500",
+		},
+		{
+			name:   "long press",
+			config: DefaultGestureConfig,
+			steps:  []step{press(0, ButtonA), tick(499), tick(600), release(900), tick(2000)},
+			want:   "A LongPress 1@500",
+		},
+		{
+			name:   "long press after a pending tap",
+			config: DefaultGestureConfig,
+			steps:  []step{press(0, ButtonA), release(50), press(200, ButtonA), tick(700), release(800), tick(2000)},
+			want:   "A Tap 1@700, A LongPress 1@700",
+		},
+		{
+			name:   "long press detected on release",
+			config: DefaultGestureConfig,
+			steps:  []step{press(0, ButtonA), release(600), tick(2000)},
+			want:   "A LongPress 1@500",
+		},
+		{
+			name:   "repeat cadence",
+			config: GestureConfig{RepeatDelay: 300 * time.Millisecond, RepeatInterval: 100 * time.Millisecond},
+			steps:  []step{press(0, ButtonB), tick(299), tick(300), tick(550), release(560), tick(1000)},
+			want:   "B Repeat 1@300, B Repeat 2@400, B Repeat 3@500",
+		},
+		{
+			name:   "repeat interval defaults to delay",
+			config: GestureConfig{RepeatDelay: 200 * time.Millisecond},
+			steps:  []step{press(0, ButtonB), tick(650), release(700)},
+			want:   "B Repeat 1@200, B Repeat 2@400, B Repeat 3@600",
+		},
+		{
+			name:   "short press with repeat is a tap",
+			config: GestureConfig{RepeatDelay: 300 * time.Millisecond},
+			steps:  []step{press(0, ButtonB), release(100)},
+			want:   "B Tap 1@100",
+		},
+		{
+			name:   "buttons are independent",
+			config: DefaultGestureConfig,
+			steps: []step{
+				press(0, ButtonA), press(100, ButtonA|ButtonB), release(150), press(200, ButtonB), release(250), tick(1000),
+			},
+			want: "B DoubleTap 2@500, A Tap 1@400",
+		},
+	}
+	for _, tt := range tests {
+		r := NewRecognizer(tt.config)
+		got := []string{}
+		for _, st := range tt.steps {
+			now := t0.Add(time.Duration(st.ms) * time.Millisecond)
+			var gs []Gesture
+			if st.tick {
+				gs = r.Tick(now)
+			} else {
+				gs = r.Update(State{Buttons: st.buttons, Time: now})
+			}
+			for _, g := range gs {
+				got = append(got, fmt.Sprintf("%v %v %d@%d", g.Button, g.Type, g.Count, g.Time.Sub(t0)/time.Millisecond))
+			}
+		}
+		if s := strings.Join(got, ", "); s != tt.want {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, s, tt.want)
+		}
+	}
+}
+
+func TestRecognizerConfigure(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	r := NewRecognizer(DefaultGestureConfig)
+	r.Configure(ButtonX|ButtonY, GestureConfig{})
+	gs := r.Update(State{Buttons: ButtonA | ButtonX | ButtonY, Time: t0})
+	gs = append(gs, r.Tick(t0.Add(time.Second))...)
+	if len(gs) != 1 || gs[0].Button != ButtonA || gs[0].Type != GestureLongPress {
+		t.Errorf("got %+v", gs)
+	}
+	gs = r.Update(State{Time: t0.Add(2 * time.Second)})
+	if len(gs) != 2 || gs[0].Button != ButtonY || gs[1].Button != ButtonX || gs[0].Type != GestureTap {
+		t.Errorf("got %+v", gs)
+	}
+}
+
+func TestRecognizerDroppedStates(t *testing.T) {
+	// a press and release hidden by dropped States still counts as a tap
+	t0 := time.Unix(1600000000, 0)
+	r := NewRecognizer(DefaultGestureConfig)
+	gs := r.Update(State{Down: ButtonA, Up: ButtonA, Time: t0})
+	gs = append(gs, r.Tick(t0.Add(time.Second))...)
+	if len(gs) != 1 || gs[0].Type != GestureTap || !gs[0].Time.Equal(t0.Add(250*time.Millisecond)) {
+		t.Errorf("got %+v", gs)
+	}
+}
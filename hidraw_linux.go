@@ -0,0 +1,178 @@
+package joycon
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/flynn/hid"
+)
+
+// Report sizes from the Joy-Con / Pro Controller HID descriptor, including the report ID.
+const (
+	hidrawOutputReportLength = 49
+	hidrawInputReportLength  = 362
+)
+
+var defaultHidraw = &hidrawBackend{sysfs: "/sys", dev: "/dev"}
+
+func init() {
+	RegisterBackend("hidraw", defaultHidraw)
+}
+
+// hidrawBackend talks to /dev/hidraw* nodes directly, without cgo.
+// Paths look like "hidraw:/dev/hidraw0".
+type hidrawBackend struct {
+	sysfs string
+	dev   string
+}
+
+// Devices lists hidraw nodes from the uevent files under <sysfs>/class/hidraw.
+func (b *hidrawBackend) Devices() ([]*hid.DeviceInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(b.sysfs, "class", "hidraw"))
+	if err != nil {
+		return nil, err
+	}
+	res := []*hid.DeviceInfo{}
+	for _, e := range entries {
+		info, err := b.deviceInfo(e.Name())
+		if err != nil {
+			continue
+		}
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+	return res, nil
+}
+
+func (b *hidrawBackend) deviceInfo(name string) (*hid.DeviceInfo, error) {
+	uevent, err := readUevent(filepath.Join(b.sysfs, "class", "hidraw", name, "device", "uevent"))
+	if err != nil {
+		return nil, err
+	}
+	// HID_ID=<bus>:<vendor>:<product>
+	id := strings.Split(uevent["HID_ID"], ":")
+	if len(id) != 3 {
+		return nil, errors.New("invalid HID_ID: " + uevent["HID_ID"])
+	}
+	vendor, err := strconv.ParseUint(id[1], 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	product, err := strconv.ParseUint(id[2], 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	return &hid.DeviceInfo{
+		Path:               "hidraw:" + filepath.Join(b.dev, name),
+		VendorID:           uint16(vendor),
+		ProductID:          uint16(product),
+		Product:            uevent["HID_NAME"],
+		InputReportLength:  hidrawInputReportLength,
+		OutputReportLength: hidrawOutputReportLength,
+	}, nil
+}
+
+// Serial returns HID_UNIQ of a /dev/hidraw* node, the Bluetooth MAC for controllers.
+func (b *hidrawBackend) Serial(path string) string {
+	uevent, err := readUevent(filepath.Join(b.sysfs, "class", "hidraw", filepath.Base(path), "device", "uevent"))
+	if err != nil {
+		return ""
+	}
+	return uevent["HID_UNIQ"]
+}
+
+// Open opens a /dev/hidraw* node in non-blocking mode.
+func (b *hidrawBackend) Open(path string) (Transport, error) {
+	info, err := b.deviceInfo(filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := &hidrawTransport{
+		info: info,
+		f:    f,
+		ch:   make(chan []byte, 16),
+	}
+	go t.read()
+	return t, nil
+}
+
+func readUevent(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		kv := strings.SplitN(sc.Text(), "=", 2)
+		if len(kv) == 2 {
+			res[kv[0]] = kv[1]
+		}
+	}
+	return res, sc.Err()
+}
+
+// hidrawTransport ...
+type hidrawTransport struct {
+	info *hid.DeviceInfo
+	f    *os.File
+	ch   chan []byte
+}
+
+func (t *hidrawTransport) Write(b []byte) error {
+	for {
+		_, err := t.f.Write(b)
+		if !errors.Is(err, syscall.EAGAIN) {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (t *hidrawTransport) ReadCh() <-chan []byte {
+	return t.ch
+}
+
+func (t *hidrawTransport) Close() {
+	t.f.Close()
+}
+
+func (t *hidrawTransport) OutputReportLength() int {
+	return hidrawOutputReportLength
+}
+
+func (t *hidrawTransport) Info() *hid.DeviceInfo {
+	return t.info
+}
+
+func (t *hidrawTransport) read() {
+	defer close(t.ch)
+	buf := make([]byte, hidrawInputReportLength)
+	for {
+		// hidraw nodes are pollable, so the runtime poller parks this goroutine
+		// instead of a thread; EAGAIN only shows up if polling is unavailable.
+		n, err := t.f.Read(buf)
+		if errors.Is(err, syscall.EAGAIN) {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return
+		}
+		rep := make([]byte, n)
+		copy(rep, buf[:n])
+		t.ch <- rep
+	}
+}
@@ -0,0 +1,114 @@
+package joycon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSysfs builds <dir>/class/hidraw/<name>/device/uevent files.
+func fakeSysfs(t *testing.T, uevents map[string]string) string {
+	dir := t.TempDir()
+	for name, uevent := range uevents {
+		d := filepath.Join(dir, "class", "hidraw", name, "device")
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "uevent"), []byte(uevent), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newFakeHidraw(t *testing.T) *hidrawBackend {
+	return &hidrawBackend{
+		sysfs: fakeSysfs(t, map[string]string{
+			"hidraw0": "DRIVER=hid-generic\nHID_ID=0003:0000046D:0000C52B\nHID_NAME=Logitech USB Receiver\n",
+			"hidraw1": "DRIVER=nintendo\nHID_ID=0005:0000057E:00002006\nHID_NAME=Joy-Con (L)\nHID_UNIQ=98:b6:e9:01:02:03\n",
+			"hidraw2": "HID_ID=0005:0000057E:00002007\nHID_NAME=Joy-Con (R)\nHID_UNIQ=98:b6:e9:04:05:06\n",
+			"hidraw3": "HID_ID=0005:0000057E:00002009\nHID_NAME=Pro Controller\n",
+			"hidraw4": "HID_ID=garbage\nHID_NAME=broken\n",
+			"hidraw5": "HID_ID=0005:zz:00002006\n",
+			"hidraw6": "HID_NAME=no id\n",
+		}),
+		dev: "/dev",
+	}
+}
+
+func TestHidrawDevices(t *testing.T) {
+	b := newFakeHidraw(t)
+	devices, err := b.Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// malformed HID_ID entries are skipped
+	want := []struct {
+		path    string
+		vendor  uint16
+		product uint16
+		name    string
+	}{
+		{"hidraw:/dev/hidraw0", 0x046d, 0xc52b, "Logitech USB Receiver"},
+		{"hidraw:/dev/hidraw1", 0x057e, 0x2006, "Joy-Con (L)"},
+		{"hidraw:/dev/hidraw2", 0x057e, 0x2007, "Joy-Con (R)"},
+		{"hidraw:/dev/hidraw3", 0x057e, 0x2009, "Pro Controller"},
+	}
+	if len(devices) != len(want) {
+		t.Fatalf("got %d devices, want %d", len(devices), len(want))
+	}
+	for i, w := range want {
+		d := devices[i]
+		if d.Path != w.path || d.VendorID != w.vendor || d.ProductID != w.product || d.Product != w.name {
+			t.Errorf("device %d: got %+v", i, d)
+		}
+		if d.OutputReportLength != hidrawOutputReportLength || d.InputReportLength != hidrawInputReportLength {
+			t.Errorf("device %d: report lengths %d/%d", i, d.InputReportLength, d.OutputReportLength)
+		}
+	}
+}
+
+func TestHidrawDevicesNoSysfs(t *testing.T) {
+	b := &hidrawBackend{sysfs: t.TempDir(), dev: "/dev"}
+	if _, err := b.Devices(); err == nil {
+		t.Error("expected an error without class/hidraw")
+	}
+}
+
+func TestHidrawSearchBackend(t *testing.T) {
+	RegisterBackend("hidraw-test", newFakeHidraw(t))
+	devices, err := SearchBackend("hidraw-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 3 {
+		t.Errorf("got %d controllers, want 3", len(devices))
+	}
+	devices, err = SearchBackend("hidraw-test", JoyConR, ProCon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[0].ProductID != 0x2007 || devices[1].ProductID != 0x2009 {
+		t.Errorf("got %+v", devices)
+	}
+	RegisterBackend("hidraw-empty", &hidrawBackend{sysfs: fakeSysfs(t, map[string]string{
+		"hidraw0": "HID_ID=0003:0000046D:0000C52B\n",
+	}), dev: "/dev"})
+	if _, err := SearchBackend("hidraw-empty"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestHidrawSerial(t *testing.T) {
+	b := newFakeHidraw(t)
+	if s := b.Serial("/dev/hidraw1"); s != "98:b6:e9:01:02:03" {
+		t.Errorf("got %q", s)
+	}
+	if s := b.Serial("/dev/hidraw3"); s != "" {
+		t.Errorf("got %q for a device without HID_UNIQ", s)
+	}
+	if s := b.Serial("/dev/hidraw9"); s != "" {
+		t.Errorf("got %q for a missing device", s)
+	}
+}
@@ -0,0 +1,200 @@
+package joycon
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+)
+
+// GyroSensitivity is the gyroscope full scale range.
+type GyroSensitivity byte
+
+// Gyroscope ranges in degrees per second.
+const (
+	Gyro250DPS  GyroSensitivity = 0x00
+	Gyro500DPS  GyroSensitivity = 0x01
+	Gyro1000DPS GyroSensitivity = 0x02
+	Gyro2000DPS GyroSensitivity = 0x03
+)
+
+// DPS returns the range in degrees per second.
+func (g GyroSensitivity) DPS() float32 {
+	return [...]float32{250, 500, 1000, 2000}[g&0x03]
+}
+
+// AccelSensitivity is the accelerometer full scale range.
+type AccelSensitivity byte
+
+// Accelerometer ranges in G.
+const (
+	Accel8G  AccelSensitivity = 0x00
+	Accel4G  AccelSensitivity = 0x01
+	Accel2G  AccelSensitivity = 0x02
+	Accel16G AccelSensitivity = 0x03
+)
+
+// G returns the range in G.
+func (a AccelSensitivity) G() float32 {
+	return [...]float32{8, 4, 2, 16}[a&0x03]
+}
+
+// GyroPerformance is the gyroscope output data rate.
+type GyroPerformance byte
+
+// Gyroscope performance rates.
+const (
+	GyroPerformance833Hz GyroPerformance = 0x00
+	GyroPerformance208Hz GyroPerformance = 0x01
+)
+
+// AccelFilter is the accelerometer anti-aliasing filter bandwidth.
+type AccelFilter byte
+
+// Accelerometer filter bandwidths.
+const (
+	AccelFilter200Hz AccelFilter = 0x00
+	AccelFilter100Hz AccelFilter = 0x01
+)
+
+// IMUConfig is the 6-axis sensor setup of subcommand 0x41.
+type IMUConfig struct {
+	GyroRange   GyroSensitivity
+	AccelRange  AccelSensitivity
+	GyroPerf    GyroPerformance
+	AccelFilter AccelFilter
+}
+
+// DefaultIMUConfig is the setup applied by the controller when the 6-axis sensor is enabled.
+var DefaultIMUConfig = IMUConfig{
+	GyroRange:   Gyro2000DPS,
+	AccelRange:  Accel8G,
+	GyroPerf:    GyroPerformance208Hz,
+	AccelFilter: AccelFilter100Hz,
+}
+
+// AccelCoeff is G per LSB of raw accelerometer data.
+func (c IMUConfig) AccelCoeff() float32 {
+	return 2 * c.AccelRange.G() / 65535
+}
+
+// GyroCoeff is degrees per second per LSB of raw gyroscope data.
+func (c IMUConfig) GyroCoeff() float32 {
+	return 2 * c.GyroRange.DPS() / 65535
+}
+
+// MarshalBinary ...
+func (c IMUConfig) MarshalBinary() ([]byte, error) {
+	return []byte{byte(c.GyroRange), byte(c.AccelRange), byte(c.GyroPerf), byte(c.AccelFilter)}, nil
+}
+
+func (c IMUConfig) pack() uint32 {
+	b, _ := c.MarshalBinary()
+	return binary.LittleEndian.Uint32(b)
+}
+
+func unpackIMUConfig(v uint32) IMUConfig {
+	return IMUConfig{
+		GyroRange:   GyroSensitivity(v),
+		AccelRange:  AccelSensitivity(v >> 8),
+		GyroPerf:    GyroPerformance(v >> 16),
+		AccelFilter: AccelFilter(v >> 24),
+	}
+}
+
+// IMUConfig returns the active 6-axis sensor setup.
+func (jc *Joycon) IMUConfig() IMUConfig {
+	return unpackIMUConfig(atomic.LoadUint32(&jc.imuConfig))
+}
+
+// ConfigureIMU sets the 6-axis sensor ranges and rates (subcommand 0x41).
+// Sensor values are scaled to the new ranges, and the setup is kept across reconnects.
+func (jc *Joycon) ConfigureIMU(gyroRange GyroSensitivity, accelRange AccelSensitivity, gyroPerf GyroPerformance, accelFilter AccelFilter) error {
+	c := IMUConfig{
+		GyroRange:   gyroRange & 0x03,
+		AccelRange:  accelRange & 0x03,
+		GyroPerf:    gyroPerf & 0x01,
+		AccelFilter: accelFilter & 0x01,
+	}
+	b, _ := c.MarshalBinary()
+	if _, err := jc.Subcommand(append([]byte{0x41}, b...)); err != nil {
+		return err
+	}
+	atomic.StoreUint32(&jc.imuConfig, c.pack())
+	return nil
+}
+
+// SPI addresses of the 6-axis calibration.
+const (
+	FactoryIMUCalibrationAddr uint32 = 0x6020
+	UserIMUCalibrationAddr    uint32 = 0x8026 // 2 bytes magic 0xB2 0xA1, then the calibration
+)
+
+// IMUCalibration is the 24 bytes 6-axis calibration block of SPI flash.
+// Sensitivities are the raw readings at 4G (accel) and 936 degrees per second (gyro)
+// with DefaultIMUConfig.
+type IMUCalibration struct {
+	AccelOrigin      [3]int16
+	AccelSensitivity [3]int16
+	GyroOffset       [3]int16
+	GyroSensitivity  [3]int16
+	// User is set when the block came from the user calibration area.
+	User bool
+}
+
+// UnmarshalBinary ...
+func (c *IMUCalibration) UnmarshalBinary(b []byte) error {
+	if len(b) < 24 {
+		return &ShortReportError{Length: len(b), Want: 24}
+	}
+	for i, v := range []*[3]int16{&c.AccelOrigin, &c.AccelSensitivity, &c.GyroOffset, &c.GyroSensitivity} {
+		for n := 0; n < 3; n++ {
+			p := i*6 + n*2
+			v[n] = int16(binary.LittleEndian.Uint16(b[p : p+2]))
+		}
+	}
+	return nil
+}
+
+// accel converts raw axis n to G for range g.
+func (c *IMUCalibration) accel(n int, raw float32, g float32) float32 {
+	d := float32(c.AccelSensitivity[n]) - float32(c.AccelOrigin[n])
+	if d == 0 {
+		return raw * 2 * g / 65535
+	}
+	return (raw - float32(c.AccelOrigin[n])) * 4 / d * g / 8
+}
+
+// gyro converts raw axis n to degrees per second for range dps.
+func (c *IMUCalibration) gyro(n int, raw float32, dps float32) float32 {
+	d := float32(c.GyroSensitivity[n]) - float32(c.GyroOffset[n])
+	if d == 0 {
+		return raw * 2 * dps / 65535
+	}
+	return (raw - float32(c.GyroOffset[n])) * 936 / d * dps / 2000
+}
+
+// IMUCalibration returns the 6-axis calibration applied to Sensor values.
+func (jc *Joycon) IMUCalibration() IMUCalibration {
+	if c, ok := jc.imuCal.Load().(IMUCalibration); ok {
+		return c
+	}
+	return IMUCalibration{}
+}
+
+// readIMUCalibration reads the user calibration, or the factory one when there is none.
+func (jc *Joycon) readIMUCalibration(ctx context.Context) (IMUCalibration, error) {
+	c := IMUCalibration{}
+	data, err := jc.readSPI(ctx, UserIMUCalibrationAddr, 26)
+	if err != nil {
+		return c, err
+	}
+	if data[0] == 0xb2 && data[1] == 0xa1 {
+		c.User = true
+		return c, c.UnmarshalBinary(data[2:])
+	}
+	data, err = jc.readSPI(ctx, FactoryIMUCalibrationAddr, 24)
+	if err != nil {
+		return c, err
+	}
+	return c, c.UnmarshalBinary(data)
+}
@@ -0,0 +1,151 @@
+package joycon
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-3
+}
+
+func TestIMUCalibrationUnmarshalBinary(t *testing.T) {
+	b := make([]byte, 24)
+	for i, v := range []int16{-10, 20, 30, 16384, 16000, 16300, 1, -2, 3, 13371, 13000, 13500} {
+		binary.LittleEndian.PutUint16(b[i*2:], uint16(v))
+	}
+	c := IMUCalibration{}
+	if err := c.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	want := IMUCalibration{
+		AccelOrigin:      [3]int16{-10, 20, 30},
+		AccelSensitivity: [3]int16{16384, 16000, 16300},
+		GyroOffset:       [3]int16{1, -2, 3},
+		GyroSensitivity:  [3]int16{13371, 13000, 13500},
+	}
+	if c != want {
+		t.Errorf("got %+v", c)
+	}
+	if err := c.UnmarshalBinary(b[:23]); err == nil {
+		t.Error("short block accepted")
+	}
+}
+
+func TestIMUCalibrationConvert(t *testing.T) {
+	c := IMUCalibration{
+		AccelOrigin:      [3]int16{0, 100, -100},
+		AccelSensitivity: [3]int16{16384, 16484, 16284},
+		GyroOffset:       [3]int16{0, 10, -10},
+		GyroSensitivity:  [3]int16{13371, 13381, 13361},
+	}
+	accel := []struct {
+		n    int
+		raw  float32
+		g    float32
+		want float32
+	}{
+		{0, 4096, 8, 1},
+		{0, 16384, 8, 4},
+		{0, 4096, 16, 2},
+		{0, 4096, 2, 0.25},
+		{1, 100, 8, 0},
+		{1, 4196, 8, 1},
+		{2, -4196, 4, -0.5},
+	}
+	for _, tt := range accel {
+		if got := c.accel(tt.n, tt.raw, tt.g); !near(got, tt.want) {
+			t.Errorf("accel(%d, %v, %vG) = %v, want %v", tt.n, tt.raw, tt.g, got, tt.want)
+		}
+	}
+	gyro := []struct {
+		n    int
+		raw  float32
+		dps  float32
+		want float32
+	}{
+		{0, 13371, 2000, 936},
+		{0, 13371, 1000, 468},
+		{0, -13371, 250, -117},
+		{1, 10, 2000, 0},
+		{1, 13381, 2000, 936},
+		{2, -13381, 500, -234},
+	}
+	for _, tt := range gyro {
+		if got := c.gyro(tt.n, tt.raw, tt.dps); !near(got, tt.want) {
+			t.Errorf("gyro(%d, %v, %vdps) = %v, want %v", tt.n, tt.raw, tt.dps, got, tt.want)
+		}
+	}
+	// without calibration the raw value spans the range
+	zero := IMUCalibration{}
+	if got := zero.accel(0, 32767.5, 8); !near(got, 8) {
+		t.Errorf("uncalibrated accel = %v", got)
+	}
+	if got := zero.gyro(0, -32767.5, 2000); !near(got, -2000) {
+		t.Errorf("uncalibrated gyro = %v", got)
+	}
+}
+
+func TestSensorsUnmarshalBinaryWith(t *testing.T) {
+	b := make([]byte, 49)
+	b[0], b[1] = 0x30, 10
+	for n := 0; n < 3; n++ {
+		p := 13 + n*12
+		binary.LittleEndian.PutUint16(b[p:], uint16(4096*(n+1)))
+		binary.LittleEndian.PutUint16(b[p+6:], uint16(13371))
+	}
+	cal := IMUCalibration{AccelSensitivity: [3]int16{16384, 16384, 16384}, GyroSensitivity: [3]int16{13371, 13371, 13371}}
+	c := IMUConfig{GyroRange: Gyro1000DPS, AccelRange: Accel16G}
+	s := Sensors{}
+	if err := s.UnmarshalBinaryWith(b, c, &cal); err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 3; n++ {
+		if s[n].Tick != byte(8+n) {
+			t.Errorf("sensor %d tick %d", n, s[n].Tick)
+		}
+		if !near(s[n].Accel.X, 2*float32(n+1)) || !near(s[n].Gyro.X, 468) {
+			t.Errorf("sensor %d: accel %v gyro %v", n, s[n].Accel, s[n].Gyro)
+		}
+	}
+}
+
+func TestReadIMUCalibration(t *testing.T) {
+	block := func(spi map[uint32]byte, addr uint32, accelSens int16) {
+		b := make([]byte, 24)
+		for i := 3; i < 6; i++ {
+			binary.LittleEndian.PutUint16(b[i*2:], uint16(accelSens))
+		}
+		for i, v := range b {
+			spi[addr+uint32(i)] = v
+		}
+	}
+	for _, user := range []bool{false, true} {
+		f := newFakeController(0x02)
+		block(f.spi, FactoryIMUCalibrationAddr, 16000)
+		if user {
+			f.spi[UserIMUCalibrationAddr], f.spi[UserIMUCalibrationAddr+1] = 0xb2, 0xa1
+			block(f.spi, UserIMUCalibrationAddr+2, 16500)
+		} else {
+			// a user block without the magic is ignored
+			block(f.spi, UserIMUCalibrationAddr+2, 16500)
+		}
+		jc, err := NewJoyconWithTransport(f, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := jc.Subcommand([]byte{0x02}); err != nil {
+			t.Fatal(err)
+		}
+		c := jc.IMUCalibration()
+		want := int16(16000)
+		if user {
+			want = 16500
+		}
+		if c.User != user || c.AccelSensitivity != [3]int16{want, want, want} {
+			t.Errorf("user %v: got %+v", user, c)
+		}
+		jc.Close()
+	}
+}
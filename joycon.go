@@ -4,8 +4,9 @@ package joycon
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"image/color"
 	"io"
 	"log"
 	"math"
@@ -23,30 +24,41 @@ var (
 			{0x01, 0x02}, // Connect2
 			{0x01, 0x03}, // Connect3
 		*/
-		{0x30, 0x01}, // Set PlayerLED
+		// Set PlayerLED: sent by setup from WithPlayerLights/SetPlayerLights
 		{0x40, 0x01}, // Enable 6axis Sensor
 		{0x48, 0x01}, // Enable Vibration
-		{0x03, 0x30}, // Set Standard full mode. Pushes current state @60Hz
+		// Set input mode: sent by setup, InputModeStandardFull unless changed by SetInputMode
 	}
 	disconnectSeq = [][]byte{
 		{0x30, 0x00}, // Clear PlayerLED
 		{0x40, 0x00}, // Disable 6axis Sensor
 		{0x48, 0x00}, // Disable Vibration
 		{0x03, 0x3f}, // Set Normal HID Mode
-		//{0x06, 0x00}, // HCI Disconnect
+		// HCI Disconnect: sent by session with WithPowerOffOnClose
 	}
 )
 
+// replyTimeout bounds a reply wait when the context has no deadline.
+const replyTimeout = time.Second
+
 type sub struct {
-	cmd []byte
-	rep chan<- []byte
+	ctx     context.Context
+	cmd     []byte
+	match   func(*SubcommandReply) bool
+	noReply bool
+	rep     chan<- subResult
+}
+
+type subResult struct {
+	reply *SubcommandReply
+	err   error
 }
 
 // Joycon ...
 type Joycon struct {
 	info         *hid.DeviceInfo
 	closeOnce    sync.Once
-	device       hid.Device
+	transport    Transport
 	rumble       chan []byte
 	report       chan []byte
 	state        chan State
@@ -57,21 +69,63 @@ type Joycon struct {
 	sub          chan sub
 	closing      chan struct{}
 	done         chan struct{}
-	color        color.Color
+	colors       atomic.Value // Colors
 	count        byte
 	leftEnable   bool
 	rightEnable  bool
-	leftStick    CalibInfo
-	rightStick   CalibInfo
+	sticks       atomic.Value // stickSetup
 	stats        Stats
 	sendRumble   chan<- []byte
 	muSendRumble sync.RWMutex
 	interval     *time.Ticker
+	devinfo      DeviceInfo
+	playerLights uint32
+	powerOff     bool
+	inputMode    uint32
+	imuConfig    uint32
+	imuCal       atomic.Value // IMUCalibration
+	imuEnabled   uint32       // 1 while the 6-axis sensor is on (subcommand 0x40)
+	reconnect    bool
+	connEvents   chan ConnectionEvent
+}
+
+// Option configures a Joycon at creation.
+type Option func(*Joycon)
+
+// WithReconnect keeps the Joycon alive when the connection is lost:
+// it waits for the same controller (matched by its Bluetooth MAC address)
+// to show up again on the backend of the device path, sets it up again and
+// resumes the State/Sensor channels. See ConnectionEvents.
+func WithReconnect() Option {
+	return func(jc *Joycon) {
+		jc.reconnect = true
+	}
 }
 
 // NewJoycon ...
-func NewJoycon(devicePath string, irenable bool) (*Joycon, error) {
+func NewJoycon(devicePath string, irenable bool, opts ...Option) (*Joycon, error) {
+	return NewJoyconContext(context.Background(), devicePath, irenable, opts...)
+}
+
+// NewJoyconContext is NewJoycon that gives up when ctx is done.
+// Without a deadline every reply from the controller is still bounded by a default timeout.
+func NewJoyconContext(ctx context.Context, devicePath string, irenable bool, opts ...Option) (*Joycon, error) {
+	t, err := OpenTransport(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	return newJoycon(ctx, t, irenable, opts)
+}
+
+// NewJoyconWithTransport creates a Joycon over t.
+// The transport is closed when initialization fails or Close is called.
+func NewJoyconWithTransport(t Transport, irenable bool, opts ...Option) (*Joycon, error) {
+	return newJoycon(context.Background(), t, irenable, opts)
+}
+
+func newJoycon(ctx context.Context, t Transport, irenable bool, opts []Option) (*Joycon, error) {
 	jc := &Joycon{
+		transport:  t,
 		rumble:     make(chan []byte, 6),
 		report:     make(chan []byte, 16),
 		state:      make(chan State, 16),
@@ -83,28 +137,37 @@ func NewJoycon(devicePath string, irenable bool) (*Joycon, error) {
 		closing:    make(chan struct{}),
 		done:       make(chan struct{}),
 		interval:   time.NewTicker(5 * time.Millisecond),
+		connEvents: make(chan ConnectionEvent, 4),
 	}
-	jc.sendRumble = jc.rumble
-	info, err := hid.ByPath(devicePath)
-	if err != nil {
-		return nil, err
+	jc.playerLights = uint32(LED1)
+	jc.inputMode = uint32(InputModeStandardFull)
+	jc.imuConfig = DefaultIMUConfig.pack()
+	for _, opt := range opts {
+		opt(jc)
 	}
-	jc.info = info
-	device, err := info.Open()
-	if err != nil {
-		return nil, err
+	jc.sendRumble = jc.rumble
+	if it, ok := t.(infoTransport); ok {
+		jc.info = it.Info()
+	} else {
+		jc.info = &hid.DeviceInfo{OutputReportLength: uint16(t.OutputReportLength())}
 	}
-	jc.device = device
-	go jc.receive()
-	if err := jc.subcommand(nil, []byte{0x03, 0x3f}); err != nil {
+	go jc.receive(t, jc.report)
+	if err := jc.init(ctx); err != nil {
+		jc.interval.Stop()
+		t.Close()
 		return nil, err
 	}
-	if _, err := jc.reply(); err != nil {
-		return nil, err
+	go jc.run()
+	return jc, nil
+}
+
+func (jc *Joycon) init(ctx context.Context) error {
+	if _, err := jc.request(ctx, nil, []byte{0x03, byte(InputModeSimpleHID)}); err != nil {
+		return err
 	}
-	data, err := jc.ReadSPI(0x6012, 1)
+	data, err := jc.readSPI(ctx, 0x6012, 1)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	switch data[0] {
 	case 0x01:
@@ -115,10 +178,25 @@ func NewJoycon(devicePath string, irenable bool) (*Joycon, error) {
 		jc.leftEnable = true
 		jc.rightEnable = true
 	default:
-		return nil, fmt.Errorf("unknown product type: %d", data[0])
+		return &UnknownProductError{Type: data[0]}
 	}
-	go jc.run()
-	return jc, nil
+	jc.sticks.Store(stickSetup{dt: jc.deviceType()})
+	devinfo, err := jc.requestDeviceInfo(ctx)
+	if err != nil {
+		return err
+	}
+	jc.devinfo = devinfo
+	return nil
+}
+
+func (jc *Joycon) requestDeviceInfo(ctx context.Context) (DeviceInfo, error) {
+	var di DeviceInfo
+	r, err := jc.request(ctx, nil, []byte{0x02})
+	if err != nil {
+		return di, err
+	}
+	err = di.UnmarshalBinary(r.Data)
+	return di, err
 }
 
 // Close ...
@@ -130,7 +208,9 @@ func (jc *Joycon) Close() {
 		jc.muSendRumble.Unlock()
 		close(jc.rumble)
 		<-jc.done
-		jc.device.Close()
+		if jc.transport != nil {
+			jc.transport.Close()
+		}
 	})
 }
 
@@ -149,18 +229,56 @@ func (jc *Joycon) IRData() <-chan IRData {
 	return jc.irdata
 }
 
-// Subcommand ...
-func (jc *Joycon) Subcommand(b []byte) ([]byte, error) {
-	ch := make(chan []byte, 1)
-	jc.sub <- sub{
-		cmd: b,
-		rep: ch,
+// ConnectionEvents reports Disconnected/Reconnected when WithReconnect is set.
+// Events are dropped when the channel is not drained.
+func (jc *Joycon) ConnectionEvents() <-chan ConnectionEvent {
+	return jc.connEvents
+}
+
+// Subcommand sends a subcommand (ID and arguments) and returns the reply
+// echoing its ID. A NACK is returned as *SubcommandNACKError.
+func (jc *Joycon) Subcommand(b []byte) (*SubcommandReply, error) {
+	return jc.SubcommandContext(context.Background(), b)
+}
+
+// SubcommandContext is Subcommand that waits until ctx is done.
+// Without a deadline the whole call is bounded by a default timeout.
+func (jc *Joycon) SubcommandContext(ctx context.Context, b []byte) (*SubcommandReply, error) {
+	return jc.subcommandContext(ctx, b, nil)
+}
+
+func (jc *Joycon) subcommandContext(ctx context.Context, b []byte, match func(*SubcommandReply) bool) (*SubcommandReply, error) {
+	return jc.send(ctx, sub{ctx: ctx, cmd: b, match: match})
+}
+
+// send passes v to the run loop and waits for its result.
+// The hand-off counts against the deadline too: the run loop is busy
+// while it sets up or waits for a reconnect.
+func (jc *Joycon) send(ctx context.Context, v sub) (*SubcommandReply, error) {
+	if len(v.cmd) == 0 {
+		return nil, fmt.Errorf("empty subcommand")
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, replyTimeout)
+		defer cancel()
+	}
+	v.ctx = ctx
+	ch := make(chan subResult, 1)
+	v.rep = ch
+	select {
+	case <-ctx.Done():
+		return nil, ctxError(ctx)
+	case <-jc.done:
+		return nil, ErrClosed
+	case jc.sub <- v:
 	}
-	rep, ok := <-ch
-	if !ok {
-		return nil, fmt.Errorf("reply receive failed")
+	select {
+	case <-ctx.Done():
+		return nil, ctxError(ctx)
+	case res := <-ch:
+		return res.reply, res.err
 	}
-	return rep, nil
 }
 
 // SendRumble ...
@@ -191,19 +309,53 @@ func (jc *Joycon) IsRight() bool {
 	return !jc.leftEnable && jc.rightEnable
 }
 
+// deviceType is the DeviceType read from SPI at setup.
+func (jc *Joycon) deviceType() DeviceType {
+	switch {
+	case jc.IsLeft():
+		return JoyConL
+	case jc.IsRight():
+		return JoyConR
+	}
+	return ProCon
+}
+
 // IsProCon ...
 func (jc *Joycon) IsProCon() bool {
 	return jc.leftEnable && jc.rightEnable
 }
 
+// stickSetup is the stick calibration read from SPI.
+// setup publishes it as a whole so receive never sees a partial update.
+type stickSetup struct {
+	dt                      DeviceType
+	left, right             CalibInfo
+	leftParams, rightParams StickParams
+}
+
+func (jc *Joycon) stickSetup() stickSetup {
+	sc, _ := jc.sticks.Load().(stickSetup)
+	return sc
+}
+
 // LeftStickCalibration ...
 func (jc *Joycon) LeftStickCalibration() CalibInfo {
-	return jc.leftStick
+	return jc.stickSetup().left
 }
 
 // RightStickCalibration ...
 func (jc *Joycon) RightStickCalibration() CalibInfo {
-	return jc.rightStick
+	return jc.stickSetup().right
+}
+
+// LeftStickParams ...
+func (jc *Joycon) LeftStickParams() StickParams {
+	return jc.stickSetup().leftParams
+}
+
+// RightStickParams ...
+func (jc *Joycon) RightStickParams() StickParams {
+	return jc.stickSetup().rightParams
 }
 
 // Name ...
@@ -211,6 +363,12 @@ func (jc *Joycon) Name() string {
 	return jc.info.Product
 }
 
+// DeviceInfo returns firmware version, controller type and Bluetooth MAC
+// address, as answered to subcommand 0x02 when the Joycon was created.
+func (jc *Joycon) DeviceInfo() DeviceInfo {
+	return jc.devinfo
+}
+
 // Stats ...
 func (jc *Joycon) Stats() Stats {
 	return Stats{
@@ -223,7 +381,7 @@ func (jc *Joycon) Stats() Stats {
 
 func (jc *Joycon) subcommand(rumble, cmd []byte) error {
 	defer func() { <-jc.interval.C }()
-	buf := make([]byte, jc.info.OutputReportLength)
+	buf := make([]byte, jc.transport.OutputReportLength())
 	if len(cmd) == 0 {
 		buf[0] = 0x10
 	} else {
@@ -233,60 +391,97 @@ func (jc *Joycon) subcommand(rumble, cmd []byte) error {
 	copy(buf[2:10], rumble)
 	copy(buf[10:], cmd)
 	jc.count = (jc.count + 1) & 15
-	return jc.device.Write(buf)
+	return jc.transport.Write(buf)
 }
 
-func (jc *Joycon) reply() ([]byte, error) {
-	rep, ok := <-jc.report
-	if !ok {
-		return nil, fmt.Errorf("report closed")
+// request sends cmd and waits for its reply. Only for the goroutine driving the transport.
+func (jc *Joycon) request(ctx context.Context, rumble, cmd []byte) (*SubcommandReply, error) {
+	if err := jc.subcommand(rumble, cmd); err != nil {
+		return nil, err
 	}
-	return rep, nil
+	r, err := jc.replyContext(ctx, cmd[0], nil)
+	if err == nil {
+		jc.track(cmd)
+	}
+	return r, err
 }
 
-// ReadSPI ...
-func (jc *Joycon) ReadSPI(addr uint16, length int) ([]byte, error) {
-	var rep []byte
-	for i := 0; i < 100; i++ {
-		if err := jc.subcommand(nil, []byte{0x10, byte(addr & 0xff), byte(addr >> 8), 0x00, 0x00, byte(length)}); err != nil {
-			return nil, err
-		}
-		r, err := jc.reply()
-		if err != nil {
-			return nil, err
-		}
-		if uint16(r[15])|uint16(r[16])<<8 == addr {
-			rep = r[20 : 20+length]
-			break
+// track keeps the controller setup receive depends on, cmd has been acknowledged.
+func (jc *Joycon) track(cmd []byte) {
+	if len(cmd) >= 2 && cmd[0] == 0x40 {
+		atomic.StoreUint32(&jc.imuEnabled, uint32(cmd[1]&0x01))
+	}
+}
+
+func (jc *Joycon) reply(id byte) (*SubcommandReply, error) {
+	return jc.replyContext(context.Background(), id, nil)
+}
+
+// replyContext waits for the 0x21 report echoing subcommand id (and accepted
+// by match if set), skipping stale replies of earlier subcommands.
+func (jc *Joycon) replyContext(ctx context.Context, id byte, match func(*SubcommandReply) bool) (*SubcommandReply, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, replyTimeout)
+		defer cancel()
+	}
+	for {
+		select {
+		case rep, ok := <-jc.report:
+			if !ok {
+				return nil, ErrClosed
+			}
+			r := &SubcommandReply{}
+			if err := r.UnmarshalBinary(rep); err != nil || r.ID != id {
+				continue
+			}
+			// match first: a late NACK of an earlier request with the same ID is stale too
+			if match != nil && !match(r) {
+				continue
+			}
+			if !r.Acked() {
+				return nil, &SubcommandNACKError{ID: id}
+			}
+			jc.adjust(&r.State)
+			return r, nil
+		case <-ctx.Done():
+			return nil, ctxError(ctx)
 		}
 	}
-	return rep, nil
 }
 
-func (jc *Joycon) receive() {
-	defer close(jc.report)
+// ctxError maps a deadline to an error matching ErrTimeout.
+func ctxError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return timeoutError{}
+	}
+	return ctx.Err()
+}
+
+func (jc *Joycon) receive(t Transport, report chan<- []byte) {
+	defer close(report)
+	edges := buttonEdges{}
 	for {
 		select {
-		case rep, ok := <-jc.device.ReadCh():
+		case rep, ok := <-t.ReadCh():
 			if !ok {
 				return
 			}
 			switch rep[0] {
 			case 0x30:
 				// gyro & accel
-				s := Sensors{}
-				if err := s.UnmarshalBinary(rep); err != nil {
+				if err := jc.sensors(rep); err != nil {
 					return
 				}
-				atomic.AddUint64(&jc.stats.SensorCount, 1)
-				for n := 0; n < 3; n++ {
-					select {
-					case jc.sensor <- s[n]:
-					default:
-					}
-				}
 				continue
 			case 0x31:
+				// gyro & accel, same layout as 0x30, garbage while the IMU is off
+				if jc.InputMode() == InputModeNFCIR && atomic.LoadUint32(&jc.imuEnabled) != 0 {
+					jc.sensors(rep)
+				}
+				if len(rep) < 362 {
+					continue
+				}
 				// IR data
 				data := IRData{}
 				if err := data.UnmarshalBinary(rep[49:362]); err != nil {
@@ -300,186 +495,247 @@ func (jc *Joycon) receive() {
 				}
 				continue
 			case 0x3f:
-				log.Println("")
+				dt := jc.stickSetup().dt
+				if dt == 0 {
+					// controller type not read yet
+					continue
+				}
+				s := &State{Time: time.Now()}
+				if err := s.UnmarshalSimpleHID(rep, dt); err == nil {
+					jc.adjust(s)
+					edges.add(s)
+				} else {
+					s.Err = err
+				}
+				jc.sendState(s, &edges)
+				continue
 			case 0x32, 0x33:
 				log.Printf("rep: %X", rep)
+				continue
 			case 0x21:
-				s := &State{}
+				s := &State{Time: time.Now()}
 				if err := s.UnmarshalBinary(rep); err == nil {
-					if jc.leftEnable {
-						s.LeftAdj = jc.calibration(jc.leftStick, s.Left)
-					}
-					if jc.rightEnable {
-						s.RightAdj = jc.calibration(jc.rightStick, s.Right)
-					}
+					jc.adjust(s)
+					edges.add(s)
 				} else {
 					s.Err = err
 				}
-				select {
-				case jc.state <- *s:
-					atomic.AddUint64(&jc.stats.StateCount, 1)
-				default:
-				}
+				jc.sendState(s, &edges)
 			default:
+				continue
 			}
-			jc.report <- rep
+			report <- rep
+		}
+	}
+}
+
+// sendState delivers s if the State channel has room, edges carry over otherwise.
+func (jc *Joycon) sendState(s *State, edges *buttonEdges) {
+	select {
+	case jc.state <- *s:
+		atomic.AddUint64(&jc.stats.StateCount, 1)
+		if s.Err == nil {
+			edges.delivered()
+		}
+	default:
+	}
+}
+
+// sensors decodes the 6-axis data of a 0x30 or 0x31 report.
+func (jc *Joycon) sensors(rep []byte) error {
+	s := Sensors{}
+	cal := jc.IMUCalibration()
+	if err := s.UnmarshalBinaryWith(rep, jc.IMUConfig(), &cal); err != nil {
+		return err
+	}
+	atomic.AddUint64(&jc.stats.SensorCount, 1)
+	for n := 0; n < 3; n++ {
+		select {
+		case jc.sensor <- s[n]:
+		default:
 		}
 	}
+	return nil
 }
 
 func (jc *Joycon) run() {
 	defer close(jc.done)
-	if jc.leftEnable {
-		data, err := jc.ReadSPI(0x8012, 9)
-		if err != nil {
+	for {
+		err := jc.session()
+		if err == nil {
+			return
+		}
+		if !jc.reconnect {
 			jc.state <- State{Err: err}
 			return
 		}
+		jc.notify(Disconnected, err)
+		if !jc.waitReconnect() {
+			return
+		}
+		jc.notify(Reconnected, nil)
+	}
+}
+
+// session runs setup and the report loop until Close (nil) or connection loss.
+func (jc *Joycon) session() error {
+	if err := jc.setup(); err != nil {
+		return err
+	}
+	if err := jc.loop(); err != nil {
+		return err
+	}
+	// best effort: a rejected or unanswered step does not stop the others
+	alive := true
+	for _, seq := range disconnectSeq {
+		_, err := jc.request(context.Background(), nil, seq)
+		var nack *SubcommandNACKError
+		if err != nil && !errors.As(err, &nack) && !errors.Is(err, ErrTimeout) {
+			log.Println(err)
+			alive = false
+			break
+		}
+	}
+	if jc.powerOff && alive {
+		// no reply, the controller drops the connection
+		jc.subcommand(nil, []byte{0x06, byte(HCIDisconnect)})
+	}
+	return nil
+}
+
+func (jc *Joycon) setup() error {
+	sc := jc.stickSetup()
+	if jc.leftEnable {
+		data, err := jc.readSPI(context.Background(), 0x8012, 9)
+		if err != nil {
+			return err
+		}
 		if !bytes.Equal(data, bytes.Repeat([]byte{0xff}, 9)) {
-			jc.leftStick.UnmarshalBinary(data)
+			sc.left.UnmarshalBinary(data)
 		} else {
-			data, err = jc.ReadSPI(0x603d, 9)
+			data, err = jc.readSPI(context.Background(), 0x603d, 9)
 			if err != nil {
-				jc.state <- State{Err: err}
-				return
+				return err
 			}
 			if !bytes.Equal(data, bytes.Repeat([]byte{0xff}, 9)) {
-				jc.leftStick.UnmarshalBinary(data)
+				sc.left.UnmarshalBinary(data)
 
 			}
 		}
 	}
-	data, err := jc.ReadSPI(0x6050, 3)
+	data, err := jc.readSPI(context.Background(), ColorRegion.Addr, ColorRegion.Length)
 	if err != nil {
-		jc.state <- State{Err: err}
-		return
+		return err
 	}
-	jc.color = color.RGBA{data[0], data[1], data[2], 0}
+	colors := Colors{}
+	if err := colors.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	jc.colors.Store(colors)
 	if jc.rightEnable {
-		data, err := jc.ReadSPI(0x801d, 9)
+		data, err := jc.readSPI(context.Background(), 0x801d, 9)
 		if err != nil {
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
 		if !bytes.Equal(data, bytes.Repeat([]byte{0xff}, 9)) {
 			d := make([]byte, 0, 9)
 			d = append(d, data[6:9]...)
 			d = append(d, data[0:3]...)
 			d = append(d, data[3:6]...)
-			jc.rightStick.UnmarshalBinary(d)
+			sc.right.UnmarshalBinary(d)
 		} else {
-			data, err = jc.ReadSPI(0x6046, 9)
+			data, err = jc.readSPI(context.Background(), 0x6046, 9)
 			if err != nil {
-				jc.state <- State{Err: err}
-				return
+				return err
 			}
 			if !bytes.Equal(data, bytes.Repeat([]byte{0xff}, 9)) {
 				d := make([]byte, 0, 9)
 				d = append(d, data[6:9]...)
 				d = append(d, data[0:3]...)
 				d = append(d, data[3:6]...)
-				jc.rightStick.UnmarshalBinary(d)
+				sc.right.UnmarshalBinary(d)
 			}
 		}
 	}
 	// LeftStick Deadzone
-	_, err = jc.ReadSPI(0x6086, 16)
-	if err != nil {
-		jc.state <- State{Err: err}
-		return
+	if sc.leftParams, err = jc.readStickParams(0x6086); err != nil {
+		return err
 	}
 	// RightStick Deadzone
-	_, err = jc.ReadSPI(0x6098, 16)
-	if err != nil {
-		jc.state <- State{Err: err}
-		return
+	if sc.rightParams, err = jc.readStickParams(0x6098); err != nil {
+		return err
 	}
-	// Gyro Parameters (user)
-	_, err = jc.ReadSPI(0x8034, 10)
+	jc.sticks.Store(sc)
+	// 6-Axis calibration (user or factory)
+	cal, err := jc.readIMUCalibration(context.Background())
 	if err != nil {
-		jc.state <- State{Err: err}
-		return
+		return err
 	}
-	// Gyro Parameters (sys)
-	_, err = jc.ReadSPI(0x6029, 10)
-	if err != nil {
-		jc.state <- State{Err: err}
-		return
+	jc.imuCal.Store(cal)
+	if _, err := jc.request(context.Background(), nil, []byte{0x30, byte(atomic.LoadUint32(&jc.playerLights))}); err != nil {
+		return err
 	}
 	for _, seq := range connectSeq {
-		if err := jc.subcommand(nil, seq); err != nil {
-			jc.state <- State{Err: err}
-			return
+		if _, err := jc.request(context.Background(), nil, seq); err != nil {
+			return err
 		}
-		if _, err := jc.reply(); err != nil {
-			jc.state <- State{Err: err}
-			return
+	}
+	if c := jc.IMUConfig(); c != DefaultIMUConfig {
+		b, _ := c.MarshalBinary()
+		if _, err := jc.request(context.Background(), nil, append([]byte{0x41}, b...)); err != nil {
+			return err
 		}
 	}
-	defer func() {
-		for _, seq := range disconnectSeq {
-			if err := jc.subcommand(nil, seq); err != nil {
-				jc.state <- State{Err: err}
-				return
-			}
-			if _, err := jc.reply(); err != nil {
-				jc.state <- State{Err: err}
-				return
-			}
+	if !jc.irenable {
+		if err := jc.setInputMode(jc.InputMode()); err != nil {
+			return err
 		}
-	}()
+	}
 	if jc.irenable {
 		if err := jc.subcommand(nil, []byte{0x40, 0x00}); err != nil {
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
-		r, err := jc.reply()
+		r, err := jc.reply(0x40)
 		if err != nil {
 			log.Println(err)
-			jc.state <- State{Err: err}
-			return
-		}
-		log.Printf("r:%X", r)
-		if err := jc.subcommand(nil, []byte{0x03, 0x31}); err != nil {
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
-		r, err = jc.reply()
-		if err != nil {
+		jc.track([]byte{0x40, 0x00})
+		log.Printf("r:%X", r.Data)
+		if err := jc.setInputMode(InputModeNFCIR); err != nil {
 			log.Println(err)
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
-		log.Printf("r:%X", r)
 		if err := jc.subcommand(nil, []byte{0x11, 0x03, 0x00}); err != nil {
 			log.Println(err)
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
-		r, err = jc.reply()
+		r, err = jc.reply(0x11)
 		if err != nil {
 			log.Println(err)
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
-		log.Printf("r:%X", r)
+		log.Printf("r:%X", r.Data)
 		jc.outputcode = 0x11
 		if err := jc.subcommand(nil, []byte{0x03, 0x00}); err != nil {
 			log.Println(err)
-			jc.state <- State{Err: err}
-			return
+			return err
 		}
 		/*
-			r, err = jc.reply()
+			r, err = jc.reply(0x03)
 			if err != nil {
 				log.Println(err)
 				jc.state <- State{Err: err}
 				return
 			}
-			log.Printf("r:%X", r)
+			log.Printf("r:%X", r.Data)
 		*/
 		jc.outputcode = 0x1
 	}
+	return nil
+}
+
+func (jc *Joycon) loop() error {
 	// loop
 	t := time.NewTicker(time.Millisecond * 15)
 	t2 := time.NewTimer(time.Millisecond * 120)
@@ -488,23 +744,30 @@ func (jc *Joycon) run() {
 		select {
 		case v := <-jc.sub:
 			if err := jc.subcommand(r, v.cmd); err != nil {
-				v.rep <- nil
+				v.rep <- subResult{err: err}
+				return err
 			}
-			b, err := jc.reply()
-			if err != nil {
-				v.rep <- nil
+			if v.noReply {
+				v.rep <- subResult{}
+				continue
+			}
+			b, err := jc.replyContext(v.ctx, v.cmd[0], v.match)
+			if err == nil {
+				jc.track(v.cmd)
+			}
+			v.rep <- subResult{reply: b, err: err}
+			if err == ErrClosed {
+				return err
 			}
-			v.rep <- b
 		case v, ok := <-jc.rumble:
 			if !ok {
-				return
+				return nil
 			}
 			r = v
 			atomic.AddUint64(&jc.stats.RumbleCount, 1)
 			if err := jc.subcommand(r, nil); err != nil {
 				log.Println(err)
-				jc.state <- State{Err: err}
-				return
+				return err
 			}
 			t2.Reset(time.Millisecond * 120)
 		case <-t2.C:
@@ -514,36 +777,204 @@ func (jc *Joycon) run() {
 			default:
 			case v, ok := <-jc.rumble:
 				if !ok {
-					return
+					return nil
 				}
 				r = v
 			}
+			if jc.InputMode() == InputModeSimpleHID {
+				// no polling, the controller pushes on button changes
+				if err := jc.subcommand(r, nil); err != nil {
+					log.Println(err)
+					return err
+				}
+				continue
+			}
 			if err := jc.subcommand(r, []byte{0}); err != nil {
 				log.Println(err)
-				jc.state <- State{Err: err}
-				return
+				return err
+			}
+			if _, err := jc.reply(0x00); err == ErrClosed {
+				return err
+			}
+		}
+	}
+}
+
+func (jc *Joycon) notify(typ ConnectionEventType, err error) {
+	select {
+	case jc.connEvents <- ConnectionEvent{Type: typ, Time: time.Now(), Err: err}:
+	default:
+	}
+}
+
+// waitReconnect closes the lost transport and polls the backend until the
+// same controller is back. It returns false when the Joycon is closed meanwhile.
+func (jc *Joycon) waitReconnect() bool {
+	jc.transport.Close()
+	jc.transport = nil
+	enumerate := devicesFor(jc.info.Path)
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-jc.closing:
+			return false
+		case <-t.C:
+		}
+		devices, err := enumerate()
+		if err != nil {
+			continue
+		}
+		for _, device := range devices {
+			if device.VendorID != jc.info.VendorID || device.ProductID != jc.info.ProductID {
+				continue
+			}
+			// skip other controllers without opening them when the backend knows the MAC
+			if serial := DeviceSerial(device); serial != "" && !sameMAC(serial, jc.devinfo.MAC) {
+				continue
+			}
+			if err := jc.attach(device.Path); err == nil {
+				return true
+			}
+		}
+	}
+}
+
+// attach opens path and keeps it when it is the controller we lost.
+// Only the device info is queried until the MAC matches, so another
+// controller in use elsewhere is left as it is and none of its
+// reports show up as States.
+func (jc *Joycon) attach(path string) error {
+	t, err := OpenTransport(path)
+	if err != nil {
+		return err
+	}
+	jc.transport = t
+	di, err := jc.probeDeviceInfo(t)
+	if err == nil && !bytes.Equal(di.MAC, jc.devinfo.MAC) {
+		err = fmt.Errorf("another device: %s", di.MAC)
+	}
+	if err != nil {
+		t.Close()
+		jc.transport = nil
+		return err
+	}
+	jc.report = make(chan []byte, 16)
+	go jc.receive(t, jc.report)
+	return nil
+}
+
+// probeDeviceInfo is requestDeviceInfo reading t directly, before receive runs.
+func (jc *Joycon) probeDeviceInfo(t Transport) (DeviceInfo, error) {
+	var di DeviceInfo
+	if err := jc.subcommand(nil, []byte{0x02}); err != nil {
+		return di, err
+	}
+	timeout := time.NewTimer(replyTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case rep, ok := <-t.ReadCh():
+			if !ok {
+				return di, ErrClosed
+			}
+			r := &SubcommandReply{}
+			if len(rep) == 0 || rep[0] != 0x21 || r.UnmarshalBinary(rep) != nil || r.ID != 0x02 {
+				continue
+			}
+			if !r.Acked() {
+				return di, &SubcommandNACKError{ID: 0x02}
 			}
-			jc.reply()
+			return di, di.UnmarshalBinary(r.Data)
+		case <-timeout.C:
+			return di, ErrTimeout
 		}
 	}
 }
 
-func (jc *Joycon) calibration(c CalibInfo, s Stick) Vec2 {
+// simpleHIDCalib spans the 8-bit axes of 0x3F reports scaled to 12 bits,
+// centered at 0x80 (X) and 0xff-0x80 (Y, inverted).
+var simpleHIDCalib = CalibInfo{
+	Center: Stick{X: 0x800, Y: 0x7f0},
+	Min:    Stick{X: 0x800, Y: 0x7f0},
+	Max:    Stick{X: 0x7f0, Y: 0x800},
+}
+
+// adjust fills the calibrated stick values of s.
+func (jc *Joycon) adjust(s *State) {
+	sc := jc.stickSetup()
+	left, right := sc.left, sc.right
+	if s.LowResolution {
+		// the 12-bit factory calibration does not fit the 8-bit axes
+		left, right = simpleHIDCalib, simpleHIDCalib
+	}
+	if sc.dt == JoyConL || sc.dt == ProCon {
+		s.LeftAdj = jc.calibration(left, sc.leftParams, s.Left)
+	}
+	if sc.dt == JoyConR || sc.dt == ProCon {
+		s.RightAdj = jc.calibration(right, sc.rightParams, s.Right)
+	}
+}
+
+// readStickParams reads a stick parameter block, DefaultStickParams when blank.
+func (jc *Joycon) readStickParams(addr uint32) (StickParams, error) {
+	p := DefaultStickParams
+	data, err := jc.readSPI(context.Background(), addr, 18)
+	if err != nil {
+		return p, err
+	}
+	if bytes.Equal(data[3:6], []byte{0xff, 0xff, 0xff}) {
+		return p, nil
+	}
+	return p, p.UnmarshalBinary(data)
+}
+
+func (jc *Joycon) calibration(c CalibInfo, p StickParams, s Stick) Vec2 {
 	var res Vec2
-	diff := float32(s.X) - float32(c.Center.X)
-	if math.Abs(float64(diff)) < 0xae { // TODO: deadzone from SPI
-		diff = 0.0
+	dx := float32(s.X) - float32(c.Center.X)
+	dy := float32(s.Y) - float32(c.Center.Y)
+	// radial deadzone
+	dz := float64(p.Deadzone)
+	if math.Hypot(float64(dx), float64(dy)) < dz {
+		return res
 	}
-	if diff > 0 {
-		res.X = diff / float32(c.Max.X)
+	if dx > 0 {
+		res.X = dx / float32(c.Max.X)
 	} else {
-		res.X = diff / float32(c.Min.X)
+		res.X = dx / float32(c.Min.X)
 	}
-	diff = float32(s.Y) - float32(c.Center.Y)
-	if diff > 0 {
-		res.Y = diff / float32(c.Max.Y)
+	if dy > 0 {
+		res.Y = dy / float32(c.Max.Y)
 	} else {
-		res.Y = diff / float32(c.Min.Y)
+		res.Y = dy / float32(c.Min.Y)
+	}
+	// rescale so the output starts from 0 at the deadzone edge
+	r := float64(c.Max.X) + float64(c.Min.X) + float64(c.Max.Y) + float64(c.Min.Y)
+	if r <= 0 {
+		return res
+	}
+	n := dz * 4 / r
+	m := math.Hypot(float64(res.X), float64(res.Y))
+	if m <= n {
+		return Vec2{}
 	}
+	if n < 1 {
+		k := float32((m - n) / (1 - n) / m)
+		res.X *= k
+		res.Y *= k
+	}
+	// the rescale pushes diagonals past the axis range
+	res.X = clampUnit(res.X)
+	res.Y = clampUnit(res.Y)
 	return res
 }
+
+func clampUnit(v float32) float32 {
+	switch {
+	case v > 1:
+		return 1
+	case v < -1:
+		return -1
+	}
+	return v
+}
@@ -0,0 +1,535 @@
+package joycon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flynn/hid"
+)
+
+// fakeController is an in-memory Transport answering subcommands with
+// 0x21 reports like a controller in standard full mode.
+type fakeController struct {
+	typ  byte // SPI 0x6012: 1 JoyConL, 2 JoyConR, 3 ProCon
+	mac  []byte
+	spi  map[uint32]byte // 0xff where unset
+	nack map[byte]bool   // subcommand IDs answered with a NACK
+	btn  byte            // right button byte of every report
+	ch   chan []byte
+
+	mu     sync.Mutex
+	writes [][]byte
+	closed bool
+}
+
+func newFakeController(typ byte) *fakeController {
+	return &fakeController{
+		typ:  typ,
+		mac:  []byte{0x98, 0xb6, 0xe9, 0x01, 0x02, 0x03},
+		spi:  map[uint32]byte{0x6012: typ},
+		nack: map[byte]bool{},
+		ch:   make(chan []byte, 64),
+	}
+}
+
+func (f *fakeController) Write(b []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrClosed
+	}
+	f.writes = append(f.writes, append([]byte(nil), b...))
+	if b[0] != 0x01 || b[10] == 0x06 {
+		// rumble only, or HCI state without reply
+		return nil
+	}
+	id := b[10]
+	rep := make([]byte, 49)
+	rep[0], rep[1], rep[2] = 0x21, byte(len(f.writes)), 0x8e
+	rep[3], rep[13], rep[14] = f.btn, 0x80, id
+	switch id {
+	case 0x02:
+		rep[13] = 0x82
+		rep[15], rep[16], rep[17], rep[18] = 0x03, 0x8b, f.typ, 0x02
+		copy(rep[19:25], f.mac)
+	case 0x10:
+		rep[13] = 0x90
+		addr, n := binary.LittleEndian.Uint32(b[11:15]), uint32(b[15])
+		copy(rep[15:20], b[11:16])
+		for i := uint32(0); i < n; i++ {
+			v, ok := f.spi[addr+i]
+			if !ok {
+				v = 0xff
+			}
+			rep[20+i] = v
+		}
+	}
+	if f.nack[id] {
+		rep[13] = 0x00
+	}
+	f.ch <- rep
+	return nil
+}
+
+func (f *fakeController) ReadCh() <-chan []byte   { return f.ch }
+func (f *fakeController) OutputReportLength() int { return 49 }
+
+func (f *fakeController) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		f.closed = true
+		close(f.ch)
+	}
+}
+
+func (f *fakeController) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// sent reports whether a subcommand starting with cmd was written.
+func (f *fakeController) sent(cmd ...byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, w := range f.writes {
+		if w[0] == 0x01 && bytes.HasPrefix(w[10:], cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewJoyconWithTransport(t *testing.T) {
+	f := newFakeController(0x02)
+	jc, err := NewJoyconWithTransport(f, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !jc.IsRight() {
+		t.Error("not a right Joy-Con")
+	}
+	di := jc.DeviceInfo()
+	if di.Type != JoyConR || !bytes.Equal(di.MAC, f.mac) {
+		t.Errorf("device info %+v", di)
+	}
+	r, err := jc.Subcommand([]byte{0x02})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != 0x02 || !r.Acked() || !bytes.Equal(r.Data[4:10], f.mac) {
+		t.Errorf("reply %+v", r)
+	}
+	select {
+	case s := <-jc.State():
+		if s.Err != nil {
+			t.Errorf("state %v", s.Err)
+		}
+	case <-time.After(time.Second):
+		t.Error("no State")
+	}
+	jc.Close()
+	if !f.isClosed() {
+		t.Error("transport not closed")
+	}
+}
+
+func TestNewJoyconWithTransportUnknownProduct(t *testing.T) {
+	f := newFakeController(0x07)
+	_, err := NewJoyconWithTransport(f, false)
+	var pe *UnknownProductError
+	if !errors.As(err, &pe) || pe.Type != 0x07 {
+		t.Errorf("got %v, want UnknownProductError", err)
+	}
+	if !f.isClosed() {
+		t.Error("transport not closed")
+	}
+}
+
+func TestSubcommandTimeoutWhileRunLoopBusy(t *testing.T) {
+	// nobody receives from sub: the run loop is stuck in setup or a reconnect
+	jc := &Joycon{sub: make(chan sub), done: make(chan struct{})}
+	start := time.Now()
+	_, err := jc.Subcommand([]byte{0x30, 0x01})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%v does not match context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d < replyTimeout || d > replyTimeout+time.Second {
+		t.Errorf("returned after %v", d)
+	}
+}
+
+func TestCtxError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	err := ctxError(ctx)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("deadline: %v", err)
+	}
+	if err.Error() != ErrTimeout.Error() {
+		t.Errorf("message %q", err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = ctxError(ctx)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrTimeout) {
+		t.Errorf("canceled: %v", err)
+	}
+}
+
+func TestPowerOffOnCloseAfterNACK(t *testing.T) {
+	f := newFakeController(0x01)
+	jc, err := NewJoyconWithTransport(f, false, WithPowerOffOnClose())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the run loop answers only after setup
+	if _, err := jc.Subcommand([]byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+	// the controller rejects some of the disconnect steps
+	f.mu.Lock()
+	f.nack[0x48] = true
+	f.nack[0x30] = true
+	f.mu.Unlock()
+	jc.Close()
+	for _, cmd := range [][]byte{{0x30, 0x00}, {0x40, 0x00}, {0x48, 0x00}, {0x03, 0x3f}, {0x06, 0x00}} {
+		if !f.sent(cmd...) {
+			t.Errorf("%x not sent", cmd)
+		}
+	}
+}
+
+// reply21 builds a 0x21 report answering subcommand id.
+func reply21(ack, id byte, data ...byte) []byte {
+	rep := make([]byte, 49)
+	rep[0], rep[13], rep[14] = 0x21, ack, id
+	copy(rep[15:], data)
+	return rep
+}
+
+func TestReplyContext(t *testing.T) {
+	spi := func(ack byte, addr uint32, data ...byte) []byte {
+		b := make([]byte, 5, 5+len(data))
+		binary.LittleEndian.PutUint32(b, addr)
+		b[4] = byte(len(data))
+		return reply21(ack, 0x10, append(b, data...)...)
+	}
+	tests := []struct {
+		name    string
+		id      byte
+		match   func(*SubcommandReply) bool
+		reports [][]byte
+		data    []byte // wanted Data prefix
+		err     error
+	}{
+		{
+			name:    "stale ids skipped",
+			id:      0x02,
+			reports: [][]byte{reply21(0x80, 0x00), reply21(0x80, 0x30), reply21(0x82, 0x02, 0x03, 0x8b)},
+			data:    []byte{0x03, 0x8b},
+		},
+		{
+			name:    "other addresses skipped",
+			id:      0x10,
+			match:   matchSPI(0x6050),
+			reports: [][]byte{spi(0x90, 0x6012, 0x02), spi(0x90, 0x6050, 0x11, 0x22)},
+			data:    []byte{0x50, 0x60, 0x00, 0x00, 0x02, 0x11, 0x22},
+		},
+		{
+			name:    "late nack of another address skipped",
+			id:      0x10,
+			match:   matchSPI(0x6050),
+			reports: [][]byte{spi(0x00, 0x6012), spi(0x90, 0x6050, 0x11)},
+			data:    []byte{0x50, 0x60, 0x00, 0x00, 0x01, 0x11},
+		},
+		{
+			name:    "nack",
+			id:      0x48,
+			reports: [][]byte{reply21(0x80, 0x40), reply21(0x00, 0x48)},
+			err:     &SubcommandNACKError{ID: 0x48},
+		},
+		{
+			name:    "nack of the address",
+			id:      0x10,
+			match:   matchSPI(0x6050),
+			reports: [][]byte{spi(0x00, 0x6050)},
+			err:     &SubcommandNACKError{ID: 0x10},
+		},
+		{
+			name:    "no reply",
+			id:      0x02,
+			reports: [][]byte{reply21(0x80, 0x03), {0x30}},
+			err:     ErrTimeout,
+		},
+	}
+	for _, tt := range tests {
+		jc := &Joycon{report: make(chan []byte, len(tt.reports))}
+		for _, rep := range tt.reports {
+			jc.report <- rep
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		r, err := jc.replyContext(ctx, tt.id, tt.match)
+		cancel()
+		switch {
+		case tt.err != nil:
+			var nack *SubcommandNACKError
+			if errors.As(tt.err, &nack) {
+				var got *SubcommandNACKError
+				if !errors.As(err, &got) || got.ID != nack.ID {
+					t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+				}
+			} else if !errors.Is(err, tt.err) {
+				t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case r.ID != tt.id || !bytes.HasPrefix(r.Data, tt.data):
+			t.Errorf("%s: got %#02x % x", tt.name, r.ID, r.Data)
+		}
+	}
+}
+
+func TestReplyContextClosed(t *testing.T) {
+	jc := &Joycon{report: make(chan []byte)}
+	close(jc.report)
+	if _, err := jc.replyContext(context.Background(), 0x02, nil); err != ErrClosed {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+}
+
+// reconnectTestBackend serves whatever controller is plugged in as "reconnecttest:jc".
+type reconnectTestBackend struct {
+	mu  sync.Mutex
+	cur *fakeController
+}
+
+var reconnectBackend = &reconnectTestBackend{}
+
+func init() {
+	RegisterBackend("reconnecttest", reconnectBackend)
+}
+
+func (b *reconnectTestBackend) plug(f *fakeController) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cur = f
+}
+
+func (b *reconnectTestBackend) info() *hid.DeviceInfo {
+	return &hid.DeviceInfo{Path: "reconnecttest:jc", VendorID: 0x057e, ProductID: uint16(JoyConR)}
+}
+
+func (b *reconnectTestBackend) Devices() ([]*hid.DeviceInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cur == nil || b.cur.isClosed() {
+		return nil, nil
+	}
+	return []*hid.DeviceInfo{b.info()}, nil
+}
+
+func (b *reconnectTestBackend) Open(path string) (Transport, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cur == nil || b.cur.isClosed() {
+		return nil, ErrNotFound
+	}
+	return fakeInfoController{b.cur, b.info()}, nil
+}
+
+type fakeInfoController struct {
+	*fakeController
+	info *hid.DeviceInfo
+}
+
+func (f fakeInfoController) Info() *hid.DeviceInfo { return f.info }
+
+func TestReconnect(t *testing.T) {
+	first := newFakeController(0x02)
+	reconnectBackend.plug(first)
+	jc, err := NewJoycon("reconnecttest:jc", false, WithReconnect(), WithPlayerLights(LED2|LED3, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jc.Close()
+	if _, err := jc.Subcommand([]byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+	wait := func(want ConnectionEventType) {
+		t.Helper()
+		select {
+		case ev := <-jc.ConnectionEvents():
+			if ev.Type != want {
+				t.Fatalf("got %v, want %v", ev.Type, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %v", want)
+		}
+	}
+	first.Close()
+	wait(Disconnected)
+
+	for len(jc.State()) > 0 {
+		<-jc.State()
+	}
+	// another controller shows up first: only its device info is queried
+	other := newFakeController(0x02)
+	other.mac = []byte{0x98, 0xb6, 0xe9, 0x0a, 0x0b, 0x0c}
+	other.btn = byte(ButtonA)
+	reconnectBackend.plug(other)
+	for !other.isClosed() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for len(jc.State()) > 0 {
+		if s := <-jc.State(); s.Buttons&ButtonA != 0 {
+			t.Error("State of the other controller published")
+		}
+	}
+	back := newFakeController(0x02)
+	reconnectBackend.plug(back)
+	wait(Reconnected)
+	other.mu.Lock()
+	for _, w := range other.writes {
+		if w[0] != 0x01 || w[10] != 0x02 {
+			t.Errorf("other controller got % x", w[:12])
+		}
+	}
+	other.mu.Unlock()
+	// answered once setup is done again
+	if _, err := jc.Subcommand([]byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+	if !back.sent(0x30, LED2|LED3) {
+		t.Error("player lights not resent")
+	}
+}
+
+func TestReceiveNFCIRSensors(t *testing.T) {
+	rep := make([]byte, 49)
+	rep[0] = 0x31
+	for _, enabled := range []bool{false, true} {
+		jc := &Joycon{state: make(chan State, 4), sensor: make(chan Sensor, 16)}
+		jc.imuConfig = DefaultIMUConfig.pack()
+		jc.inputMode = uint32(InputModeNFCIR)
+		if enabled {
+			jc.track([]byte{0x40, 0x01})
+		}
+		f := &chanTransport{ch: make(chan []byte, 4)}
+		f.ch <- rep
+		f.ch <- rep
+		close(f.ch)
+		jc.receive(f, make(chan []byte, 4))
+		want := 0
+		if enabled {
+			want = 6
+		}
+		if len(jc.sensor) != want {
+			t.Errorf("imu enabled %v: got %d Sensors, want %d", enabled, len(jc.sensor), want)
+		}
+	}
+}
+
+func TestTrackIMU(t *testing.T) {
+	f := newFakeController(0x02)
+	jc, err := NewJoyconWithTransport(f, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jc.Close()
+	if _, err := jc.Subcommand([]byte{0x02}); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint32(&jc.imuEnabled) != 1 {
+		t.Error("IMU not enabled by setup")
+	}
+	if _, err := jc.Subcommand([]byte{0x40, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint32(&jc.imuEnabled) != 0 {
+		t.Error("IMU still enabled")
+	}
+}
+
+func TestAdjustLowResolution(t *testing.T) {
+	jc := &Joycon{}
+	// typical factory calibration: center 0x800, about 0x5a0 each way
+	cal := CalibInfo{Center: Stick{0x800, 0x800}, Min: Stick{0x5a0, 0x5a0}, Max: Stick{0x5a0, 0x5a0}}
+	jc.sticks.Store(stickSetup{dt: ProCon, left: cal, right: cal, leftParams: DefaultStickParams, rightParams: DefaultStickParams})
+	for _, rep := range [][]byte{
+		{0x3f, 0, 0, 0x08, 0, 0x00, 0, 0x00, 0, 0xff, 0, 0xff},
+		{0x3f, 0, 0, 0x08, 0, 0xff, 0, 0xff, 0, 0x00, 0, 0x00},
+		{0x3f, 0, 0, 0x08, 0, 0x00, 0, 0x80, 0, 0xff, 0, 0x80},
+	} {
+		s := State{}
+		if err := s.UnmarshalSimpleHID(rep, ProCon); err != nil {
+			t.Fatal(err)
+		}
+		jc.adjust(&s)
+		for _, v := range []Vec2{s.LeftAdj, s.RightAdj} {
+			if v.X < -1 || v.X > 1 || v.Y < -1 || v.Y > 1 {
+				t.Errorf("% x: %+v out of range", rep, v)
+			}
+			if m := math.Hypot(float64(v.X), float64(v.Y)); m < 0.99 {
+				t.Errorf("% x: %+v not at full deflection", rep, v)
+			}
+		}
+	}
+	s := State{}
+	s.UnmarshalSimpleHID([]byte{0x3f, 0, 0, 0x08, 0, 0x80, 0, 0x80, 0, 0x80, 0, 0x80}, ProCon)
+	jc.adjust(&s)
+	if s.LeftAdj != (Vec2{}) || s.RightAdj != (Vec2{}) {
+		t.Errorf("centered: %+v %+v", s.LeftAdj, s.RightAdj)
+	}
+	// half way is scaled from the 8-bit range, not the factory one
+	s.UnmarshalSimpleHID([]byte{0x3f, 0, 0, 0x08, 0, 0x40, 0, 0x80, 0, 0xc0, 0, 0x80}, ProCon)
+	jc.adjust(&s)
+	if s.LeftAdj.X < -0.5 || s.LeftAdj.X > -0.4 || s.RightAdj.X < 0.4 || s.RightAdj.X > 0.5 {
+		t.Errorf("half way: %+v %+v", s.LeftAdj, s.RightAdj)
+	}
+}
+
+func TestCalibrationDeadzone(t *testing.T) {
+	jc := &Joycon{}
+	c := CalibInfo{Center: Stick{0x800, 0x800}, Min: Stick{0x5a0, 0x5a0}, Max: Stick{0x5a0, 0x5a0}}
+	p := StickParams{Deadzone: 0xae}
+	at := func(dx, dy int16) Vec2 {
+		return jc.calibration(c, p, Stick{0x800 + dx, 0x800 + dy})
+	}
+	// hypot(123, 123) = 173.9, just inside the radius of 174
+	for _, v := range []Vec2{at(0, 0), at(123, 123), at(-123, -123), at(173, 0), at(0, -173)} {
+		if v != (Vec2{}) {
+			t.Errorf("inside deadzone: %+v", v)
+		}
+	}
+	// hypot(125, 125) = 176.8, just outside
+	for _, d := range [][2]int16{{125, 125}, {-125, 125}, {-125, -125}} {
+		v := at(d[0], d[1])
+		m := math.Hypot(float64(v.X), float64(v.Y))
+		if m == 0 || m > 0.01 {
+			t.Errorf("just outside %v: %+v", d, v)
+		}
+		if (v.X > 0) != (d[0] > 0) || (v.Y > 0) != (d[1] > 0) {
+			t.Errorf("just outside %v: direction %+v", d, v)
+		}
+	}
+	// full deflection along an axis
+	if v := at(0x5a0, 0); !near(v.X, 1) || v.Y != 0 {
+		t.Errorf("full right: %+v", v)
+	}
+	if v := at(0, -0x5a0); v.X != 0 || !near(v.Y, -1) {
+		t.Errorf("full down: %+v", v)
+	}
+}
@@ -0,0 +1,108 @@
+package joycon
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Player LED bits, for both the on and the flash pattern.
+const (
+	LED1 uint8 = 1 << iota
+	LED2
+	LED3
+	LED4
+)
+
+// WithPlayerLights sets the player LED pattern applied on connect, LED1 on by default.
+func WithPlayerLights(on, flash uint8) Option {
+	return func(jc *Joycon) {
+		jc.playerLights = playerLights(on, flash)
+	}
+}
+
+func playerLights(on, flash uint8) uint32 {
+	return uint32(flash&0x0f)<<4 | uint32(on&0x0f)
+}
+
+// SetPlayerLights turns the player LEDs on or flashing (subcommand 0x30).
+// The pattern is kept across reconnects.
+func (jc *Joycon) SetPlayerLights(on, flash uint8) error {
+	v := playerLights(on, flash)
+	if _, err := jc.Subcommand([]byte{0x30, byte(v)}); err != nil {
+		return err
+	}
+	atomic.StoreUint32(&jc.playerLights, v)
+	return nil
+}
+
+// PlayerLEDs reads the current player LED pattern (subcommand 0x31).
+func (jc *Joycon) PlayerLEDs() (on, flash uint8, err error) {
+	r, err := jc.Subcommand([]byte{0x31})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(r.Data) < 1 {
+		return 0, 0, &ShortReportError{Length: len(r.Data), Want: 1}
+	}
+	return r.Data[0] & 0x0f, r.Data[0] >> 4, nil
+}
+
+// HomeLightCycle is a mini cycle of HomeLightPattern, all fields are 4 bit.
+type HomeLightCycle struct {
+	Intensity uint8 // 0x0(off) - 0xf(100%)
+	Fade      uint8 // fade transition, multiple of BaseDuration
+	Hold      uint8 // LED duration, multiple of BaseDuration
+}
+
+// HomeLightPattern is the HOME LED mini cycle pattern of subcommand 0x38,
+// all fields are 4 bit.
+type HomeLightPattern struct {
+	BaseDuration   uint8 // 0x1(8ms) - 0xf(175ms), 0x0 turns the pattern off
+	StartIntensity uint8 // 0x0(off) - 0xf(100%)
+	Repeat         uint8 // full cycles, 0 repeats forever
+	Cycles         []HomeLightCycle
+}
+
+// MarshalBinary encodes the 25 bytes argument of subcommand 0x38.
+func (p HomeLightPattern) MarshalBinary() ([]byte, error) {
+	if len(p.Cycles) > 15 {
+		return nil, fmt.Errorf("too many home light cycles: %d", len(p.Cycles))
+	}
+	if p.BaseDuration > 0xf || p.StartIntensity > 0xf || p.Repeat > 0xf {
+		return nil, fmt.Errorf("home light pattern value out of 4 bit range")
+	}
+	for _, c := range p.Cycles {
+		if c.Intensity > 0xf || c.Fade > 0xf || c.Hold > 0xf {
+			return nil, fmt.Errorf("home light cycle value out of 4 bit range")
+		}
+	}
+	res := make([]byte, 25)
+	res[0] = uint8(len(p.Cycles))<<4 | p.BaseDuration
+	res[1] = p.StartIntensity<<4 | p.Repeat
+	// cycles are packed in pairs: intensities, then fade/hold of each
+	for i, c := range p.Cycles {
+		pos := 2 + i/2*3
+		if i%2 == 0 {
+			res[pos] |= c.Intensity << 4
+			res[pos+1] = c.Fade<<4 | c.Hold
+		} else {
+			res[pos] |= c.Intensity
+			res[pos+2] = c.Fade<<4 | c.Hold
+		}
+	}
+	return res, nil
+}
+
+// SetHomeLight programs the HOME LED (subcommand 0x38).
+// Joy-Con L has no HOME LED and returns ErrUnsupported.
+func (jc *Joycon) SetHomeLight(p HomeLightPattern) error {
+	if jc.IsLeft() {
+		return ErrUnsupported
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = jc.Subcommand(append([]byte{0x38}, b...))
+	return err
+}
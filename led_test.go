@@ -0,0 +1,70 @@
+package joycon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHomeLightPatternMarshalBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		p    HomeLightPattern
+		want []byte
+	}{
+		{
+			// hid-nintendo "on": 0 cycles, 8ms base, full start intensity
+			name: "on",
+			p:    HomeLightPattern{BaseDuration: 0x1, StartIntensity: 0xf},
+			want: []byte{0x01, 0xf0},
+		},
+		{
+			name: "two cycles",
+			p: HomeLightPattern{
+				BaseDuration:   0x1,
+				StartIntensity: 0xf,
+				Cycles: []HomeLightCycle{
+					{Intensity: 0xf, Fade: 0x1, Hold: 0x1},
+					{Intensity: 0xf, Fade: 0x1, Hold: 0x1},
+				},
+			},
+			want: []byte{0x21, 0xf0, 0xff, 0x11, 0x11},
+		},
+		{
+			name: "three cycles, repeat",
+			p: HomeLightPattern{
+				BaseDuration:   0xf,
+				StartIntensity: 0x0,
+				Repeat:         0x2,
+				Cycles: []HomeLightCycle{
+					{Intensity: 0xa, Fade: 0x2, Hold: 0x3},
+					{Intensity: 0x5, Fade: 0x4, Hold: 0x5},
+					{Intensity: 0x1, Fade: 0x6, Hold: 0x7},
+				},
+			},
+			want: []byte{0x3f, 0x02, 0xa5, 0x23, 0x45, 0x10, 0x67},
+		},
+	}
+	for _, tt := range tests {
+		b, err := tt.p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(b) != 25 {
+			t.Fatalf("%s: length %d", tt.name, len(b))
+		}
+		want := make([]byte, 25)
+		copy(want, tt.want)
+		if !bytes.Equal(b, want) {
+			t.Errorf("%s: got % X, want % X", tt.name, b, want)
+		}
+	}
+}
+
+func TestHomeLightPatternRange(t *testing.T) {
+	if _, err := (HomeLightPattern{BaseDuration: 0x10}).MarshalBinary(); err == nil {
+		t.Error("expected range error")
+	}
+	if _, err := (HomeLightPattern{Cycles: make([]HomeLightCycle, 16)}).MarshalBinary(); err == nil {
+		t.Error("expected too many cycles error")
+	}
+}
@@ -0,0 +1,53 @@
+package joycon
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// InputMode is the input report mode set by subcommand 0x03.
+type InputMode uint8
+
+const (
+	// InputModePolling is active polling for NFC/IR data, used with output report 0x11.
+	InputModePolling InputMode = 0x00
+	// InputModePolling1 is the same as InputModePolling.
+	InputModePolling1 InputMode = 0x01
+	// InputModePollingMCUConfig is active polling for NFC/IR MCU configuration data.
+	InputModePollingMCUConfig InputMode = 0x02
+	// InputModePollingIR is active polling for IR camera data.
+	InputModePollingIR InputMode = 0x03
+	// InputModeMCUUpdate is the MCU update state report.
+	InputModeMCUUpdate InputMode = 0x23
+	// InputModeStandardFull pushes 0x30 reports (state and 6-axis) @60Hz.
+	InputModeStandardFull InputMode = 0x30
+	// InputModeNFCIR pushes 0x31 reports (0x30 plus NFC/IR data) @60Hz.
+	InputModeNFCIR InputMode = 0x31
+	// InputModeSimpleHID pushes 0x3F reports on every button change only.
+	InputModeSimpleHID InputMode = 0x3f
+)
+
+// InputMode returns the active input report mode.
+func (jc *Joycon) InputMode() InputMode {
+	return InputMode(atomic.LoadUint32(&jc.inputMode))
+}
+
+// SetInputMode switches the input report mode (subcommand 0x03).
+// In InputModeSimpleHID the Joycon stops polling the controller state.
+// The mode is kept across reconnects.
+func (jc *Joycon) SetInputMode(mode InputMode) error {
+	if _, err := jc.SubcommandContext(context.Background(), []byte{0x03, byte(mode)}); err != nil {
+		return err
+	}
+	atomic.StoreUint32(&jc.inputMode, uint32(mode))
+	return nil
+}
+
+// setInputMode is SetInputMode for the goroutine driving the transport.
+func (jc *Joycon) setInputMode(mode InputMode) error {
+	if _, err := jc.request(context.Background(), nil, []byte{0x03, byte(mode)}); err != nil {
+		return err
+	}
+	atomic.StoreUint32(&jc.inputMode, uint32(mode))
+	return nil
+}
@@ -0,0 +1,40 @@
+package joycon
+
+import (
+	"context"
+)
+
+// HCIState is the argument of subcommand 0x06 (Set HCI state).
+type HCIState uint8
+
+const (
+	// HCIDisconnect disconnects and puts the controller to sleep.
+	HCIDisconnect HCIState = 0x00
+	// HCIRebootReconnect reboots and reconnects (page mode).
+	HCIRebootReconnect HCIState = 0x01
+	// HCIRebootPair reboots into pairing mode.
+	HCIRebootPair HCIState = 0x02
+	// HCIRebootReconnectHome reboots and reconnects (home mode).
+	HCIRebootReconnectHome HCIState = 0x04
+)
+
+// WithPowerOffOnClose makes Close put the controller to sleep after the disconnect sequence.
+func WithPowerOffOnClose() Option {
+	return func(jc *Joycon) {
+		jc.powerOff = true
+	}
+}
+
+// Disconnect sets the HCI state (subcommand 0x06). The controller drops the
+// Bluetooth connection without a reply, so State/Sensor stop afterwards
+// unless WithReconnect picks the controller up again.
+func (jc *Joycon) Disconnect(mode HCIState) error {
+	ctx := context.Background()
+	_, err := jc.send(ctx, sub{ctx: ctx, cmd: []byte{0x06, byte(mode)}, noReply: true})
+	return err
+}
+
+// PowerOff disconnects and puts the controller to sleep.
+func (jc *Joycon) PowerOff() error {
+	return jc.Disconnect(HCIDisconnect)
+}
@@ -0,0 +1,256 @@
+package joycon
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/flynn/hid"
+)
+
+// Capture file layout (little endian):
+//
+//	header: "JCAP" version(1) productID(2) outputReportLength(2) nameLength(2) name
+//	record: offset from start in nanoseconds(8) length(2) input report
+const (
+	captureMagic   = "JCAP"
+	captureVersion = 1
+)
+
+func init() {
+	RegisterBackend("replay", replayBackend{})
+}
+
+// Recorder is a Transport that writes every input report of the
+// wrapped transport to a capture file with host timestamps.
+type Recorder struct {
+	Transport
+	w     io.Writer
+	start time.Time
+	ch    chan []byte
+	done  chan struct{}
+	once  sync.Once
+	mu    sync.Mutex
+	err   error
+}
+
+// NewRecorder writes the capture header to w and starts recording t.
+func NewRecorder(t Transport, w io.Writer) (*Recorder, error) {
+	info := &hid.DeviceInfo{}
+	if it, ok := t.(infoTransport); ok {
+		info = it.Info()
+	}
+	hdr := make([]byte, 11, 11+len(info.Product))
+	copy(hdr[0:4], captureMagic)
+	hdr[4] = captureVersion
+	binary.LittleEndian.PutUint16(hdr[5:7], info.ProductID)
+	binary.LittleEndian.PutUint16(hdr[7:9], uint16(t.OutputReportLength()))
+	binary.LittleEndian.PutUint16(hdr[9:11], uint16(len(info.Product)))
+	hdr = append(hdr, info.Product...)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		Transport: t,
+		w:         w,
+		start:     time.Now(),
+		ch:        make(chan []byte),
+		done:      make(chan struct{}),
+	}
+	go r.record()
+	return r, nil
+}
+
+// ReadCh ...
+func (r *Recorder) ReadCh() <-chan []byte {
+	return r.ch
+}
+
+// Info ...
+func (r *Recorder) Info() *hid.DeviceInfo {
+	if it, ok := r.Transport.(infoTransport); ok {
+		return it.Info()
+	}
+	return &hid.DeviceInfo{OutputReportLength: uint16(r.OutputReportLength())}
+}
+
+// Close stops recording and closes the wrapped transport.
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.done)
+		r.Transport.Close()
+	})
+}
+
+// Err returns the first capture write error.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record() {
+	defer close(r.ch)
+	in := r.Transport.ReadCh()
+	for {
+		var rep []byte
+		select {
+		case <-r.done:
+			return
+		case b, ok := <-in:
+			if !ok {
+				return
+			}
+			rep = b
+		}
+		r.mu.Lock()
+		if r.err == nil {
+			rec := make([]byte, 10, 10+len(rep))
+			binary.LittleEndian.PutUint64(rec[0:8], uint64(time.Since(r.start)))
+			binary.LittleEndian.PutUint16(rec[8:10], uint16(len(rep)))
+			rec = append(rec, rep...)
+			_, r.err = r.w.Write(rec)
+		}
+		r.mu.Unlock()
+		select {
+		case <-r.done:
+			return
+		case r.ch <- rep:
+		}
+	}
+}
+
+// Replay is a Transport that plays a capture file back with its original timing.
+// Output reports are discarded and ReadCh is closed at the end of the capture.
+type Replay struct {
+	info   *hid.DeviceInfo
+	r      *bufio.Reader
+	closer io.Closer
+	ch     chan []byte
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewReplay reads the capture header from r and starts playback.
+// If r is an io.Closer it is closed with the Replay.
+func NewReplay(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+	hdr := make([]byte, 11)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, err
+	}
+	if string(hdr[0:4]) != captureMagic || hdr[4] != captureVersion {
+		return nil, fmt.Errorf("invalid capture header")
+	}
+	name := make([]byte, binary.LittleEndian.Uint16(hdr[9:11]))
+	if _, err := io.ReadFull(br, name); err != nil {
+		return nil, err
+	}
+	rp := &Replay{
+		info: &hid.DeviceInfo{
+			VendorID:           0x057e,
+			ProductID:          binary.LittleEndian.Uint16(hdr[5:7]),
+			Product:            string(name),
+			OutputReportLength: binary.LittleEndian.Uint16(hdr[7:9]),
+		},
+		r:    br,
+		ch:   make(chan []byte),
+		done: make(chan struct{}),
+	}
+	if c, ok := r.(io.Closer); ok {
+		rp.closer = c
+	}
+	go rp.play()
+	return rp, nil
+}
+
+// Write ...
+func (rp *Replay) Write(b []byte) error {
+	select {
+	case <-rp.done:
+		return io.ErrClosedPipe
+	default:
+	}
+	return nil
+}
+
+// ReadCh ...
+func (rp *Replay) ReadCh() <-chan []byte {
+	return rp.ch
+}
+
+// Close ...
+func (rp *Replay) Close() {
+	rp.once.Do(func() {
+		close(rp.done)
+		if rp.closer != nil {
+			rp.closer.Close()
+		}
+	})
+}
+
+// OutputReportLength ...
+func (rp *Replay) OutputReportLength() int {
+	return int(rp.info.OutputReportLength)
+}
+
+// Info ...
+func (rp *Replay) Info() *hid.DeviceInfo {
+	return rp.info
+}
+
+func (rp *Replay) play() {
+	defer close(rp.ch)
+	start := time.Now()
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	hdr := make([]byte, 10)
+	for {
+		if _, err := io.ReadFull(rp.r, hdr); err != nil {
+			return
+		}
+		rep := make([]byte, binary.LittleEndian.Uint16(hdr[8:10]))
+		if _, err := io.ReadFull(rp.r, rep); err != nil {
+			return
+		}
+		at := time.Duration(binary.LittleEndian.Uint64(hdr[0:8]))
+		if d := at - time.Since(start); d > 0 {
+			timer.Reset(d)
+			select {
+			case <-rp.done:
+				return
+			case <-timer.C:
+			}
+		}
+		select {
+		case <-rp.done:
+			return
+		case rp.ch <- rep:
+		}
+	}
+}
+
+// replayBackend opens "replay:<capture file>" device paths.
+type replayBackend struct{}
+
+func (replayBackend) Devices() ([]*hid.DeviceInfo, error) {
+	return nil, nil
+}
+
+func (replayBackend) Open(path string) (Transport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := NewReplay(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	rp.info.Path = "replay:" + path
+	return rp, nil
+}
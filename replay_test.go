@@ -0,0 +1,77 @@
+package joycon
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// chanTransport is a Transport fed by a channel.
+type chanTransport struct {
+	ch     chan []byte
+	closed bool
+}
+
+func (t *chanTransport) Write(b []byte) error    { return nil }
+func (t *chanTransport) ReadCh() <-chan []byte   { return t.ch }
+func (t *chanTransport) Close()                  { t.closed = true }
+func (t *chanTransport) OutputReportLength() int { return 49 }
+
+func TestRecorderReplay(t *testing.T) {
+	src := &chanTransport{ch: make(chan []byte, 2)}
+	src.ch <- []byte{0x30, 0x01}
+	src.ch <- []byte{0x30, 0x02}
+	close(src.ch)
+	buf := &bytes.Buffer{}
+	rec, err := NewRecorder(src, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range rec.ReadCh() {
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rp, err := NewReplay(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rp.Close()
+	got := [][]byte{}
+	for rep := range rp.ReadCh() {
+		got = append(got, rep)
+	}
+	if len(got) != 2 || !bytes.Equal(got[0], []byte{0x30, 0x01}) || !bytes.Equal(got[1], []byte{0x30, 0x02}) {
+		t.Errorf("replayed %x", got)
+	}
+	if rp.OutputReportLength() != 49 {
+		t.Errorf("output report length %d", rp.OutputReportLength())
+	}
+}
+
+func TestRecorderCloseWithoutReader(t *testing.T) {
+	src := &chanTransport{ch: make(chan []byte, 1)}
+	src.ch <- []byte{0x30, 0x01}
+	rec, err := NewRecorder(src, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// let record pick up the report and block on the hand-off
+	time.Sleep(10 * time.Millisecond)
+	rec.Close()
+	rec.Close()
+	if !src.closed {
+		t.Error("wrapped transport not closed")
+	}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-rec.ReadCh():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("record still blocked after Close")
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package joycon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// SPISize is the size of the SPI flash.
+	SPISize = 0x80000
+	// SPISectorSize is the unit of EraseSPI.
+	SPISectorSize = 0x1000
+	// SPIChunkSize is the largest payload of a single SPI read or write.
+	SPIChunkSize = 0x1d
+)
+
+// SPIRegion is an address range of the SPI flash.
+type SPIRegion struct {
+	Addr   uint32
+	Length int
+}
+
+// Contains reports whether [addr, addr+length) lies within the region.
+func (r SPIRegion) Contains(addr uint32, length int) bool {
+	return addr >= r.Addr && uint64(addr)+uint64(length) <= uint64(r.Addr)+uint64(r.Length)
+}
+
+var (
+	// UserCalibrationRegion is the user calibration sector(sticks and 6-axis).
+	UserCalibrationRegion = SPIRegion{Addr: 0x8000, Length: SPISectorSize}
+	// ColorRegion holds body, buttons, left grip and right grip colors.
+	ColorRegion = SPIRegion{Addr: 0x6050, Length: 12}
+)
+
+// WritableSPIRegions returns the regions WriteSPI and EraseSPI accept without UnsafeSPI.
+func WritableSPIRegions() []SPIRegion {
+	return []SPIRegion{UserCalibrationRegion, ColorRegion}
+}
+
+// SPIOption ...
+type SPIOption func(*spiOptions)
+
+type spiOptions struct {
+	unsafe bool
+}
+
+// UnsafeSPI lifts the WritableSPIRegions guard, factory data included.
+func UnsafeSPI() SPIOption {
+	return func(o *spiOptions) {
+		o.unsafe = true
+	}
+}
+
+func checkSPIRange(addr uint32, length int, opts []SPIOption) error {
+	o := spiOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if uint64(addr)+uint64(length) > SPISize {
+		return fmt.Errorf("spi range %#x+%#x out of flash", addr, length)
+	}
+	if o.unsafe {
+		return nil
+	}
+	for _, r := range WritableSPIRegions() {
+		if r.Contains(addr, length) {
+			return nil
+		}
+	}
+	return fmt.Errorf("spi range %#x+%#x is protected", addr, length)
+}
+
+// ReadSPI reads up to SPIChunkSize bytes of the SPI flash.
+func (jc *Joycon) ReadSPI(addr uint32, length int) ([]byte, error) {
+	if length <= 0 || length > SPIChunkSize {
+		return nil, fmt.Errorf("invalid spi read length: %d", length)
+	}
+	r, err := jc.subcommandContext(context.Background(), spiCmd(0x10, addr, byte(length)), matchSPI(addr))
+	if err != nil {
+		return nil, err
+	}
+	return spiData(r, length)
+}
+
+func (jc *Joycon) readSPI(ctx context.Context, addr uint32, length int) ([]byte, error) {
+	if err := jc.subcommand(nil, spiCmd(0x10, addr, byte(length))); err != nil {
+		return nil, err
+	}
+	r, err := jc.replyContext(ctx, 0x10, matchSPI(addr))
+	if err != nil {
+		return nil, err
+	}
+	return spiData(r, length)
+}
+
+// WriteSPI writes data to the SPI flash and verifies it by reading it back.
+// Only WritableSPIRegions are accepted unless UnsafeSPI is passed.
+func (jc *Joycon) WriteSPI(addr uint32, data []byte, opts ...SPIOption) error {
+	if err := checkSPIRange(addr, len(data), opts); err != nil {
+		return err
+	}
+	for off := 0; off < len(data); off += SPIChunkSize {
+		chunk := data[off:]
+		if len(chunk) > SPIChunkSize {
+			chunk = chunk[:SPIChunkSize]
+		}
+		a := addr + uint32(off)
+		cmd := append(spiCmd(0x11, a, byte(len(chunk))), chunk...)
+		r, err := jc.Subcommand(cmd)
+		if err != nil {
+			return err
+		}
+		if err := spiStatus(r); err != nil {
+			return err
+		}
+		got, err := jc.ReadSPI(a, len(chunk))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, chunk) {
+			return fmt.Errorf("spi verify failed at %#x", a)
+		}
+	}
+	return nil
+}
+
+// EraseSPI erases the SPISectorSize sector at addr.
+// Only sectors inside WritableSPIRegions are accepted unless UnsafeSPI is passed.
+func (jc *Joycon) EraseSPI(addr uint32, opts ...SPIOption) error {
+	if addr%SPISectorSize != 0 {
+		return fmt.Errorf("spi sector address %#x not aligned", addr)
+	}
+	if err := checkSPIRange(addr, SPISectorSize, opts); err != nil {
+		return err
+	}
+	r, err := jc.Subcommand(spiCmd(0x12, addr, 0)[:5])
+	if err != nil {
+		return err
+	}
+	return spiStatus(r)
+}
+
+// spiCmd builds subcommand id with a little endian address and size.
+func spiCmd(id byte, addr uint32, size byte) []byte {
+	cmd := make([]byte, 6)
+	cmd[0] = id
+	binary.LittleEndian.PutUint32(cmd[1:5], addr)
+	cmd[5] = size
+	return cmd
+}
+
+// matchSPI accepts the SPI read reply echoing addr,
+// and replies too short to tell, which spiData rejects.
+func matchSPI(addr uint32) func(*SubcommandReply) bool {
+	return func(r *SubcommandReply) bool {
+		return len(r.Data) < 4 || binary.LittleEndian.Uint32(r.Data[0:4]) == addr
+	}
+}
+
+// spiData returns the payload after the echoed address(4) and size(1).
+func spiData(r *SubcommandReply, length int) ([]byte, error) {
+	if len(r.Data) < 5+length {
+		return nil, &ShortReportError{Length: len(r.Data), Want: 5 + length}
+	}
+	return r.Data[5 : 5+length], nil
+}
+
+// spiStatus checks the status byte of a write or erase reply, 0x01 is write protected.
+func spiStatus(r *SubcommandReply) error {
+	if len(r.Data) < 1 {
+		return &ShortReportError{Length: len(r.Data), Want: 1}
+	}
+	if r.Data[0] != 0x00 {
+		return fmt.Errorf("spi status: %#02x", r.Data[0])
+	}
+	return nil
+}
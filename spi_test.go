@@ -0,0 +1,34 @@
+package joycon
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSPIData(t *testing.T) {
+	r := &SubcommandReply{ID: 0x10, Data: []byte{0x3d, 0x60, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03}}
+	b, err := spiData(r, 3)
+	if err != nil || !bytes.Equal(b, []byte{0x01, 0x02, 0x03}) {
+		t.Errorf("spiData: %x, %v", b, err)
+	}
+	_, err = spiData(r, 4)
+	var se *ShortReportError
+	if !errors.As(err, &se) || se.Length != 8 || se.Want != 9 {
+		t.Errorf("spiData short: %v", err)
+	}
+}
+
+func TestSPIStatus(t *testing.T) {
+	if err := spiStatus(&SubcommandReply{Data: []byte{0x00}}); err != nil {
+		t.Errorf("ok status: %v", err)
+	}
+	if err := spiStatus(&SubcommandReply{Data: []byte{0x01}}); err == nil {
+		t.Error("write protected status accepted")
+	}
+	err := spiStatus(&SubcommandReply{})
+	var se *ShortReportError
+	if !errors.As(err, &se) || se.Length != 0 || se.Want != 1 {
+		t.Errorf("empty status: %v", err)
+	}
+}
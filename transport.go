@@ -0,0 +1,61 @@
+package joycon
+
+import (
+	"github.com/flynn/hid"
+)
+
+// Transport is the raw HID report channel a Joycon talks through.
+type Transport interface {
+	// Write writes an output report, the first byte is the report ID.
+	Write([]byte) error
+	// ReadCh returns a channel of input reports, closed when the device is lost.
+	ReadCh() <-chan []byte
+	// Close closes the transport.
+	Close()
+	// OutputReportLength returns the output report size including the report ID.
+	OutputReportLength() int
+}
+
+// infoTransport is implemented by transports that know their hid.DeviceInfo.
+type infoTransport interface {
+	Info() *hid.DeviceInfo
+}
+
+// hidTransport ...
+type hidTransport struct {
+	info   *hid.DeviceInfo
+	device hid.Device
+}
+
+func (t *hidTransport) Write(b []byte) error {
+	return t.device.Write(b)
+}
+
+func (t *hidTransport) ReadCh() <-chan []byte {
+	return t.device.ReadCh()
+}
+
+func (t *hidTransport) Close() {
+	t.device.Close()
+}
+
+func (t *hidTransport) OutputReportLength() int {
+	return int(t.info.OutputReportLength)
+}
+
+func (t *hidTransport) Info() *hid.DeviceInfo {
+	return t.info
+}
+
+// openHID opens devicePath via github.com/flynn/hid.
+func openHID(devicePath string) (Transport, error) {
+	info, err := hid.ByPath(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	device, err := info.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &hidTransport{info: info, device: device}, nil
+}
@@ -14,35 +14,70 @@ const (
 	ProCon  DeviceType = 0x2009
 )
 
+func (dt DeviceType) String() string {
+	switch dt {
+	case JoyConL:
+		return "JoyConL"
+	case JoyConR:
+		return "JoyConR"
+	case ProCon:
+		return "ProCon"
+	}
+	return fmt.Sprintf("DeviceType(%#x)", int(dt))
+}
+
 // Search ...
 func Search(dts ...DeviceType) ([]*hid.DeviceInfo, error) {
-	res := []*hid.DeviceInfo{}
 	devices, err := hid.Devices()
 	if err != nil {
 		return nil, err
 	}
+	return filterDevices(devices, dts)
+}
+
+// SearchBackend is Search over the devices of a registered Backend (e.g. "hidraw").
+func SearchBackend(name string, dts ...DeviceType) ([]*hid.DeviceInfo, error) {
+	b, ok := lookupBackend(name)
+	if !ok {
+		return nil, &UnknownBackendError{Name: name}
+	}
+	devices, err := b.Devices()
+	if err != nil {
+		return nil, err
+	}
+	return filterDevices(devices, dts)
+}
+
+func filterDevices(devices []*hid.DeviceInfo, dts []DeviceType) ([]*hid.DeviceInfo, error) {
+	res := []*hid.DeviceInfo{}
 	for _, device := range devices {
-		if device.VendorID != 0x057e {
-			continue
-		}
-		switch device.ProductID {
-		default:
-			continue
-		case 0x2006, 0x2007, 0x2009:
-			if len(dts) == 0 {
-				res = append(res, device)
-				continue
-			}
-			dt := DeviceType(device.ProductID)
-			for _, t := range dts {
-				if dt == t {
-					res = append(res, device)
-				}
-			}
+		if matchDevice(device, dts) {
+			res = append(res, device)
 		}
 	}
 	if len(res) == 0 {
-		return nil, fmt.Errorf("not found device")
+		return nil, ErrNotFound
 	}
 	return res, nil
 }
+
+func matchDevice(device *hid.DeviceInfo, dts []DeviceType) bool {
+	if device.VendorID != 0x057e {
+		return false
+	}
+	switch device.ProductID {
+	default:
+		return false
+	case 0x2006, 0x2007, 0x2009:
+		if len(dts) == 0 {
+			return true
+		}
+		dt := DeviceType(device.ProductID)
+		for _, t := range dts {
+			if dt == t {
+				return true
+			}
+		}
+	}
+	return false
+}
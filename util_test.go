@@ -0,0 +1,14 @@
+package joycon
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSearchBackendUnknown(t *testing.T) {
+	_, err := SearchBackend("no-such-backend")
+	var be *UnknownBackendError
+	if !errors.As(err, &be) || be.Name != "no-such-backend" {
+		t.Errorf("got %v, want UnknownBackendError", err)
+	}
+}
@@ -0,0 +1,169 @@
+package joycon
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/flynn/hid"
+)
+
+// WatchEventType ...
+type WatchEventType int
+
+const (
+	// Added is sent when a controller has been present for Watcher.Settle.
+	Added WatchEventType = iota + 1
+	// Removed is sent when a reported controller has been gone for Watcher.Settle.
+	Removed
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	}
+	return "Unknown"
+}
+
+// WatchEvent ...
+type WatchEvent struct {
+	Type       WatchEventType
+	Info       *hid.DeviceInfo
+	DeviceType DeviceType
+}
+
+// Watcher repeatedly enumerates devices and reports controllers coming and going.
+// Interfaces that show up only briefly, as happens while Bluetooth reconnects,
+// are never reported. Controllers are tracked by DeviceSerial when available,
+// so one that comes back under a new path is still the same controller.
+type Watcher struct {
+	// Enumerate lists devices, hid.Devices when nil.
+	Enumerate func() ([]*hid.DeviceInfo, error)
+	// Interval between scans, 1s when zero.
+	Interval time.Duration
+	// Settle is how long a device must stay present before Added and stay
+	// absent before Removed, 2s when zero.
+	Settle time.Duration
+	// Types limits the reported controllers, all types when empty.
+	Types []DeviceType
+}
+
+// Watch watches hid.Devices for controllers of the given types until ctx is done.
+func Watch(ctx context.Context, dts ...DeviceType) <-chan WatchEvent {
+	w := &Watcher{Types: dts}
+	return w.Watch(ctx)
+}
+
+type watchEntry struct {
+	info      *hid.DeviceInfo
+	firstSeen time.Time
+	lastSeen  time.Time
+	reported  bool
+}
+
+// Watch starts scanning, the channel is closed when ctx is done.
+func (w *Watcher) Watch(ctx context.Context) <-chan WatchEvent {
+	enumerate := w.Enumerate
+	if enumerate == nil {
+		enumerate = hid.Devices
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	settle := w.Settle
+	if settle <= 0 {
+		settle = 2 * time.Second
+	}
+	ch := make(chan WatchEvent)
+	go func() {
+		defer close(ch)
+		entries := map[string]*watchEntry{}
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			devices, err := enumerate()
+			if err == nil {
+				for _, ev := range w.scan(entries, devices, time.Now(), settle) {
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- ev:
+					}
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return ch
+}
+
+// watchKey identifies a controller across paths: its serial (Bluetooth MAC)
+// when the backend reports one, otherwise its path.
+func watchKey(device *hid.DeviceInfo) string {
+	if serial := DeviceSerial(device); serial != "" {
+		return "serial:" + serial
+	}
+	return "path:" + device.Path
+}
+
+func (w *Watcher) scan(entries map[string]*watchEntry, devices []*hid.DeviceInfo, now time.Time, settle time.Duration) []WatchEvent {
+	res := []WatchEvent{}
+	present := map[string][]*hid.DeviceInfo{}
+	keys := []string{}
+	for _, device := range devices {
+		if !matchDevice(device, w.Types) {
+			continue
+		}
+		key := watchKey(device)
+		if _, ok := present[key]; !ok {
+			keys = append(keys, key)
+		}
+		present[key] = append(present[key], device)
+	}
+	for _, key := range keys {
+		e, ok := entries[key]
+		if !ok {
+			e = &watchEntry{firstSeen: now}
+			entries[key] = e
+		}
+		// a stale path may linger next to the new one: keep the known path while it lasts
+		device := present[key][0]
+		for _, d := range present[key] {
+			if e.info != nil && d.Path == e.info.Path {
+				device = d
+			}
+		}
+		e.info = device
+		e.lastSeen = now
+		if !e.reported && now.Sub(e.firstSeen) >= settle {
+			e.reported = true
+			res = append(res, WatchEvent{Type: Added, Info: device, DeviceType: DeviceType(device.ProductID)})
+		}
+	}
+	gone := []string{}
+	for key := range entries {
+		if _, ok := present[key]; !ok {
+			gone = append(gone, key)
+		}
+	}
+	sort.Strings(gone)
+	for _, key := range gone {
+		e := entries[key]
+		switch {
+		case !e.reported:
+			delete(entries, key)
+		case now.Sub(e.lastSeen) >= settle:
+			delete(entries, key)
+			res = append(res, WatchEvent{Type: Removed, Info: e.info, DeviceType: DeviceType(e.info.ProductID)})
+		}
+	}
+	return res
+}
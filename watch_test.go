@@ -0,0 +1,123 @@
+package joycon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flynn/hid"
+)
+
+// watchTestBackend reports serials for "watchtest:" paths.
+type watchTestBackend struct {
+	serials map[string]string
+}
+
+func (b *watchTestBackend) Devices() ([]*hid.DeviceInfo, error) { return nil, nil }
+
+func (b *watchTestBackend) Open(path string) (Transport, error) { return nil, ErrUnsupported }
+
+func (b *watchTestBackend) Serial(path string) string { return b.serials[path] }
+
+func init() {
+	RegisterBackend("watchtest", &watchTestBackend{serials: map[string]string{
+		"hidraw3": "98:b6:e9:00:00:01",
+		"hidraw7": "98:b6:e9:00:00:01", // same controller after a reconnect
+		"hidraw4": "98:b6:e9:00:00:02",
+	}})
+}
+
+func watchDevice(path string, product uint16) *hid.DeviceInfo {
+	return &hid.DeviceInfo{Path: path, VendorID: 0x057e, ProductID: product}
+}
+
+func formatWatchEvents(evs []WatchEvent) string {
+	res := []string{}
+	for _, ev := range evs {
+		res = append(res, fmt.Sprintf("%v %v %s", ev.Type, ev.DeviceType, ev.Info.Path))
+	}
+	return strings.Join(res, ", ")
+}
+
+func TestWatcherScan(t *testing.T) {
+	var (
+		l     = watchDevice("watchtest:hidraw3", 0x2006)
+		lNew  = watchDevice("watchtest:hidraw7", 0x2006)
+		r     = watchDevice("watchtest:hidraw4", 0x2007)
+		pro   = watchDevice("usb:pro", 0x2009) // no serial: keyed by path
+		mouse = &hid.DeviceInfo{Path: "usb:mouse", VendorID: 0x046d, ProductID: 0xc52b}
+	)
+	settle := 2 * time.Second
+	steps := []struct {
+		sec     int
+		devices []*hid.DeviceInfo
+		want    string
+	}{
+		{0, []*hid.DeviceInfo{l, mouse}, ""},
+		{1, []*hid.DeviceInfo{l, r, mouse}, ""},
+		{2, []*hid.DeviceInfo{l, r}, "Added JoyConL watchtest:hidraw3"},
+		// r blinks away before it settles: it starts over
+		{3, []*hid.DeviceInfo{l}, ""},
+		{4, []*hid.DeviceInfo{l, r}, ""},
+		// Bluetooth reconnect: new path next to the stale one, then the stale one goes
+		{5, []*hid.DeviceInfo{l, lNew, r}, ""},
+		{6, []*hid.DeviceInfo{lNew, r}, "Added JoyConR watchtest:hidraw4"},
+		// a device seen once then gone is never reported
+		{7, []*hid.DeviceInfo{lNew, r, pro}, ""},
+		{8, []*hid.DeviceInfo{r}, ""},
+		{9, []*hid.DeviceInfo{r}, "Removed JoyConL watchtest:hidraw7"},
+		{10, []*hid.DeviceInfo{r}, ""},
+		{11, nil, ""},
+		{13, nil, "Removed JoyConR watchtest:hidraw4"},
+	}
+	w := &Watcher{}
+	entries := map[string]*watchEntry{}
+	t0 := time.Unix(1600000000, 0)
+	for _, st := range steps {
+		got := formatWatchEvents(w.scan(entries, st.devices, t0.Add(time.Duration(st.sec)*time.Second), settle))
+		if got != st.want {
+			t.Errorf("t=%ds: got %q, want %q", st.sec, got, st.want)
+		}
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d entries left", len(entries))
+	}
+}
+
+func TestWatcherTypes(t *testing.T) {
+	w := &Watcher{Types: []DeviceType{ProCon}}
+	entries := map[string]*watchEntry{}
+	devices := []*hid.DeviceInfo{watchDevice("watchtest:hidraw3", 0x2006), watchDevice("usb:pro", 0x2009)}
+	t0 := time.Unix(1600000000, 0)
+	w.scan(entries, devices, t0, time.Second)
+	got := formatWatchEvents(w.scan(entries, devices, t0.Add(time.Second), time.Second))
+	if got != "Added ProCon usb:pro" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWatcherWatch(t *testing.T) {
+	devices := []*hid.DeviceInfo{watchDevice("usb:pro", 0x2009)}
+	w := &Watcher{
+		Enumerate: func() ([]*hid.DeviceInfo, error) {
+			return devices, nil
+		},
+		Interval: time.Millisecond,
+		Settle:   time.Nanosecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := w.Watch(ctx)
+	select {
+	case ev := <-ch:
+		if ev.Type != Added || ev.Info.Path != "usb:pro" {
+			t.Errorf("got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	cancel()
+	for range ch {
+	}
+}